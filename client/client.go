@@ -0,0 +1,170 @@
+/*
+Package client implements a typed Go client for the API of the haul server.
+
+	c := client.New("http://localhost:1315", "valid-key")
+
+	components, err := c.ListComponents(ctx, types.Filter{Tags: []string{"type=ram"}}, types.ListOptions{})
+
+Every method returns an *Error when the server responds with an error status.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client calls the API of a haul server.
+//
+// Its fields must not be changed once a request was made.
+type Client struct {
+	// BaseURL is the address of the server, e.g. "https://haul.example.com:1315"
+	BaseURL string
+
+	// Key is sent as a bearer token, for servers using key authentication
+	Key string
+
+	// Timeout limits the duration of every request, 0 for no limit
+	Timeout time.Duration
+
+	// TLSConfig is used for https servers, e.g. to trust a self-signed
+	// certificate
+	TLSConfig *tls.Config
+
+	// HTTPClient, if set, is used as is instead of a client built from
+	// Timeout and TLSConfig
+	HTTPClient *http.Client
+
+	once       sync.Once
+	httpClient *http.Client
+}
+
+// New returns a Client for the server at baseURL, authenticated by key,
+// which may be empty.
+func New(baseURL, key string) *Client {
+	return &Client{BaseURL: baseURL, Key: key}
+}
+
+// client returns the http.Client shared by every request.
+func (c *Client) client() *http.Client {
+	c.once.Do(func() {
+		if c.HTTPClient != nil {
+			c.httpClient = c.HTTPClient
+			return
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = c.TLSConfig
+
+		c.httpClient = &http.Client{
+			Timeout:   c.Timeout,
+			Transport: transport,
+		}
+	})
+
+	return c.httpClient
+}
+
+// Option changes a single request, see IfMatch.
+type Option func(*http.Request)
+
+// IfMatch only applies a change if the object is still at version, as read
+// from Version or the ETag of a previous response. The server responds with
+// 412 Precondition Failed otherwise, see IsPreconditionFailed.
+func IfMatch(version int64) Option {
+	return func(request *http.Request) {
+		request.Header.Set("If-Match", fmt.Sprintf(`"%d"`, version))
+	}
+}
+
+// Force changes lent kits anyway, e.g. deleting them or moving objects out of
+// them. The server responds with 409 Conflict otherwise, see IsConflict.
+func Force() Option {
+	return func(request *http.Request) {
+		query := request.URL.Query()
+		query.Set("force", "true")
+		request.URL.RawQuery = query.Encode()
+	}
+}
+
+// contentType sends the body of a request as contentType instead of JSON.
+func contentType(contentType string) Option {
+	return func(request *http.Request) {
+		request.Header.Set("Content-Type", contentType)
+	}
+}
+
+// Do sends a request to the route of the API, with in encoded as its JSON
+// body if not nil, and decodes the JSON body of the response in out if not
+// nil. The body is copied as is to out if it is a *[]byte instead, e.g. for
+// the calendar feed.
+//
+// An *Error is returned if the response has an error status.
+func (c *Client) Do(ctx context.Context, method, route string, query url.Values, in, out interface{}, opts ...Option) (*http.Response, error) {
+	var body io.Reader
+
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	endpoint := strings.TrimSuffix(c.BaseURL, "/") + route
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Key != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Key)
+	}
+
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	for _, opt := range opts {
+		opt(request)
+	}
+
+	response, err := c.client().Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return response, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return response, newError(response, data)
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+	} else if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return response, fmt.Errorf("Invalid response to %s %s: %w", method, route, err)
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"codeberg.org/haulproject/haul/types"
+)
+
+// Error is an error status responded by the server, see types.Error.
+type Error struct {
+	StatusCode int
+
+	// Code identifies the kind of error, e.g. types.ErrorCodeNotFound
+	Code string
+
+	// Message explains the error, as given by the server
+	Message string
+
+	// Details depend on the error, see Decode
+	Details json.RawMessage
+
+	// RequestID identifies the request in the logs of the server
+	RequestID string
+
+	// Body is the raw body of the response
+	Body []byte
+
+	// ETag is the current ETag of the object on version conflicts
+	ETag string
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request %s)", e.RequestID)
+	}
+
+	return message
+}
+
+// newError returns the *Error of a response with an error status.
+func newError(response *http.Response, body []byte) *Error {
+	e := &Error{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get("X-Request-Id"),
+		Body:       body,
+		ETag:       response.Header.Get("ETag"),
+	}
+
+	var envelope struct {
+		types.Error
+		Details json.RawMessage `json:"details"`
+	}
+
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Message != "" {
+		e.Code = envelope.Code
+		e.Message = envelope.Message
+		e.Details = envelope.Details
+
+		if envelope.RequestID != "" {
+			e.RequestID = envelope.RequestID
+		}
+	} else {
+		// Not a haul server, e.g. a proxy in front of it
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+// Decode decodes the details of the error in v, e.g. the
+// types.ReferencedDetails of an object that cannot be deleted.
+func (e *Error) Decode(v interface{}) error {
+	if len(e.Details) == 0 {
+		return errors.New("The error has no details")
+	}
+
+	return json.Unmarshal(e.Details, v)
+}
+
+// StatusCode returns the status of err if it is an *Error, or 0.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 Not Found response, e.g. for a
+// missing object.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409 Conflict response, e.g. when
+// deleting an object that is still referenced.
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsPreconditionFailed reports whether err is a 412 Precondition Failed
+// response, returned when a change was made with IfMatch on an object that
+// was modified since.
+func IsPreconditionFailed(err error) bool {
+	return StatusCode(err) == http.StatusPreconditionFailed
+}
+
+// IsInvalid reports whether err is a 400 Bad Request or 422 Unprocessable
+// Entity response, returned for malformed or invalid requests.
+func IsInvalid(err error) bool {
+	status := StatusCode(err)
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// IsUnauthorized reports whether err is a 401 Unauthorized or 403 Forbidden
+// response, returned for missing or invalid keys.
+func IsUnauthorized(err error) bool {
+	status := StatusCode(err)
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// IsServerError reports whether err is a 5xx response, returned when the
+// server or its database failed.
+func IsServerError(err error) bool {
+	return StatusCode(err) >= http.StatusInternalServerError
+}
+
+// IsUnreachable reports whether err was returned because the server could not
+// be reached, e.g. when it is down or the request timed out.
+func IsUnreachable(err error) bool {
+	var e *url.Error
+	return errors.As(err, &e)
+}
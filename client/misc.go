@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+)
+
+// Routes returns the routes of the API.
+func (c *Client) Routes(ctx context.Context) ([]*echo.Route, error) {
+	var routes []*echo.Route
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1", nil, nil, &routes); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// Healthcheck returns the status of the server and of its database. An
+// *Error is returned when the database cannot be reached.
+func (c *Client) Healthcheck(ctx context.Context) (map[string]string, error) {
+	var status map[string]string
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/healthcheck", nil, nil, &status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// Statuses returns the allowed statuses and the transitions between them,
+// see types.Statuses.
+func (c *Client) Statuses(ctx context.Context) (*types.Statuses, error) {
+	var statuses types.Statuses
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/statuses", nil, nil, &statuses); err != nil {
+		return nil, err
+	}
+
+	return &statuses, nil
+}
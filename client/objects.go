@@ -0,0 +1,782 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kind is the kind of an object, as named in the routes of the API.
+type Kind string
+
+const (
+	KindComponent Kind = "component"
+	KindAssembly  Kind = "assembly"
+	KindKit       Kind = "kit"
+	KindLocation  Kind = "location"
+)
+
+// collectionKinds are the kinds of the objects of each collection.
+var collectionKinds = map[string]Kind{
+	"components": KindComponent,
+	"assemblies": KindAssembly,
+	"kits":       KindKit,
+	"locations":  KindLocation,
+}
+
+// CollectionKind returns the kind of the objects of collection, e.g. as
+// listed by Trash, or "" if it holds no objects.
+func CollectionKind(collection string) Kind {
+	return collectionKinds[collection]
+}
+
+// route returns the route of the object id of kind, followed by elements.
+func route(kind Kind, id primitive.ObjectID, elements ...string) string {
+	r := fmt.Sprintf("/v1/%s/%s", kind, id.Hex())
+
+	for _, element := range elements {
+		r += "/" + element
+	}
+
+	return r
+}
+
+// Create
+
+func (c *Client) CreateComponents(ctx context.Context, components []types.Component) (*types.InsertResult, error) {
+	return c.create(ctx, KindComponent, components)
+}
+
+func (c *Client) CreateAssemblies(ctx context.Context, assemblies []types.Assembly) (*types.InsertResult, error) {
+	return c.create(ctx, KindAssembly, assemblies)
+}
+
+func (c *Client) CreateKits(ctx context.Context, kits []types.Kit) (*types.InsertResult, error) {
+	return c.create(ctx, KindKit, kits)
+}
+
+func (c *Client) CreateLocations(ctx context.Context, locations []types.Location) (*types.InsertResult, error) {
+	return c.create(ctx, KindLocation, locations)
+}
+
+func (c *Client) create(ctx context.Context, kind Kind, objects interface{}) (*types.InsertResult, error) {
+	var result types.InsertResult
+
+	if _, err := c.Do(ctx, http.MethodPost, "/v1/"+string(kind), nil, objects, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Read
+
+func (c *Client) ReadComponent(ctx context.Context, id primitive.ObjectID) (*types.ComponentWithID, error) {
+	var component types.ComponentWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindComponent, id), nil, nil, &component); err != nil {
+		return nil, err
+	}
+
+	return &component, nil
+}
+
+func (c *Client) ReadAssembly(ctx context.Context, id primitive.ObjectID) (*types.AssemblyWithID, error) {
+	var assembly types.AssemblyWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindAssembly, id), nil, nil, &assembly); err != nil {
+		return nil, err
+	}
+
+	return &assembly, nil
+}
+
+func (c *Client) ReadKit(ctx context.Context, id primitive.ObjectID) (*types.KitWithID, error) {
+	var kit types.KitWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindKit, id), nil, nil, &kit); err != nil {
+		return nil, err
+	}
+
+	return &kit, nil
+}
+
+func (c *Client) ReadLocation(ctx context.Context, id primitive.ObjectID) (*types.LocationWithID, error) {
+	var location types.LocationWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindLocation, id), nil, nil, &location); err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+// List
+
+// listValues returns the query parameters of a list route.
+func listValues(filter types.Filter, opts types.ListOptions) url.Values {
+	values := filter.Values()
+
+	for key, value := range opts.Values() {
+		values[key] = value
+	}
+
+	return values
+}
+
+// ListComponents returns a single page of the components matching filter.
+// The following page is listed with opts.After set to the returned Next.
+func (c *Client) ListComponents(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.ComponentsWithID, error) {
+	var components types.ComponentsWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/component", listValues(filter, opts), nil, &components); err != nil {
+		return nil, err
+	}
+
+	return &components, nil
+}
+
+// ListAllComponents returns the components matching filter in every page,
+// starting from opts.After.
+func (c *Client) ListAllComponents(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.ComponentsWithID, error) {
+	var all types.ComponentsWithID
+
+	for {
+		page, err := c.ListComponents(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all.ComponentsWithID = append(all.ComponentsWithID, page.ComponentsWithID...)
+		all.Total = page.Total
+
+		if page.Next == "" {
+			return &all, nil
+		}
+
+		opts.After = page.Next
+	}
+}
+
+// ListAssemblies returns a single page of the assemblies matching filter.
+// The following page is listed with opts.After set to the returned Next.
+func (c *Client) ListAssemblies(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.AssembliesWithID, error) {
+	var assemblies types.AssembliesWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/assembly", listValues(filter, opts), nil, &assemblies); err != nil {
+		return nil, err
+	}
+
+	return &assemblies, nil
+}
+
+// ListAllAssemblies returns the assemblies matching filter in every page,
+// starting from opts.After.
+func (c *Client) ListAllAssemblies(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.AssembliesWithID, error) {
+	var all types.AssembliesWithID
+
+	for {
+		page, err := c.ListAssemblies(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all.AssembliesWithID = append(all.AssembliesWithID, page.AssembliesWithID...)
+		all.Total = page.Total
+
+		if page.Next == "" {
+			return &all, nil
+		}
+
+		opts.After = page.Next
+	}
+}
+
+// ListKits returns a single page of the kits matching filter. The following
+// page is listed with opts.After set to the returned Next.
+func (c *Client) ListKits(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.KitsWithID, error) {
+	var kits types.KitsWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/kit", listValues(filter, opts), nil, &kits); err != nil {
+		return nil, err
+	}
+
+	return &kits, nil
+}
+
+// ListAllKits returns the kits matching filter in every page, starting from
+// opts.After.
+func (c *Client) ListAllKits(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.KitsWithID, error) {
+	var all types.KitsWithID
+
+	for {
+		page, err := c.ListKits(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all.KitsWithID = append(all.KitsWithID, page.KitsWithID...)
+		all.Total = page.Total
+
+		if page.Next == "" {
+			return &all, nil
+		}
+
+		opts.After = page.Next
+	}
+}
+
+// ListLocations returns a single page of the locations matching filter. The
+// following page is listed with opts.After set to the returned Next.
+func (c *Client) ListLocations(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.LocationsWithID, error) {
+	var locations types.LocationsWithID
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/location", listValues(filter, opts), nil, &locations); err != nil {
+		return nil, err
+	}
+
+	return &locations, nil
+}
+
+// ListAllLocations returns the locations matching filter in every page,
+// starting from opts.After.
+func (c *Client) ListAllLocations(ctx context.Context, filter types.Filter, opts types.ListOptions) (*types.LocationsWithID, error) {
+	var all types.LocationsWithID
+
+	for {
+		page, err := c.ListLocations(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all.LocationsWithID = append(all.LocationsWithID, page.LocationsWithID...)
+		all.Total = page.Total
+
+		if page.Next == "" {
+			return &all, nil
+		}
+
+		opts.After = page.Next
+	}
+}
+
+// Update
+
+// change sends a change to an object, and returns its result.
+func (c *Client) change(ctx context.Context, method, route string, in interface{}, opts ...Option) (*types.UpdateResult, error) {
+	var response struct {
+		Message string `json:"message"`
+	}
+
+	if _, err := c.Do(ctx, method, route, nil, in, &response, opts...); err != nil {
+		return nil, err
+	}
+
+	var result types.UpdateResult
+
+	// Changes with nothing to do are explained by a message instead, which
+	// leaves the result empty
+	json.Unmarshal([]byte(response.Message), &result)
+
+	return &result, nil
+}
+
+// Update sets the fields of the object id of kind, e.g. {"status": "broken"}.
+// Other fields are unaffected.
+func (c *Client) Update(ctx context.Context, kind Kind, id primitive.ObjectID, fields map[string]interface{}, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPut, route(kind, id), fields, opts...)
+}
+
+// MergePatch applies a merge patch (RFC 7386) to the object id of kind, e.g.
+// {"status": "broken", "target": null} to set its status and clear its
+// target.
+func (c *Client) MergePatch(ctx context.Context, kind Kind, id primitive.ObjectID, patch map[string]interface{}, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPatch, route(kind, id), patch, append(opts, contentType(types.MergePatchContentType))...)
+}
+
+// JSONPatch applies the operations of a JSON patch (RFC 6902) to the object
+// id of kind, e.g. to add a tag with {"op": "add", "path": "/tags/-", "value":
+// "type=ram"}. A failing "test" operation is a conflict, see IsConflict.
+func (c *Client) JSONPatch(ctx context.Context, kind Kind, id primitive.ObjectID, operations []types.PatchOperation, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPatch, route(kind, id), operations, append(opts, contentType(types.JSONPatchContentType))...)
+}
+
+// Delete moves the object id of kind to the trash. onReferenced decides what
+// happens to the objects targeting it, see types.OnReferencedReject, which
+// is the default when empty.
+//
+// When the object is still referenced, the *Error holds a
+// types.ReferencedDetails, see Error.Decode.
+func (c *Client) Delete(ctx context.Context, kind Kind, id primitive.ObjectID, onReferenced string, opts ...Option) (*types.DeleteResult, error) {
+	var result types.DeleteResult
+
+	query := url.Values{}
+	if onReferenced != "" {
+		query.Set("on_referenced", onReferenced)
+	}
+
+	if _, err := c.Do(ctx, http.MethodDelete, route(kind, id), query, nil, &result, opts...); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Tags
+
+func (c *Client) Tags(ctx context.Context, kind Kind, id primitive.ObjectID) ([]string, error) {
+	var tags []string
+
+	if _, err := c.Do(ctx, http.MethodGet, route(kind, id, "tags"), nil, nil, &tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (c *Client) AddTags(ctx context.Context, kind Kind, id primitive.ObjectID, tags []string, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPost, route(kind, id, "tags", "add"), tags, opts...)
+}
+
+func (c *Client) RemoveTags(ctx context.Context, kind Kind, id primitive.ObjectID, tags []string, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPost, route(kind, id, "tags", "remove"), tags, opts...)
+}
+
+func (c *Client) ClearTags(ctx context.Context, kind Kind, id primitive.ObjectID, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodDelete, route(kind, id, "tags"), nil, opts...)
+}
+
+// TagCatalog returns the tags in use by the objects that are not in the
+// trash.
+func (c *Client) TagCatalog(ctx context.Context) (*types.TagCatalog, error) {
+	var catalog types.TagCatalog
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/tags", nil, nil, &catalog); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+// RenameTag renames a tag, or a key of structured tags, on every object.
+func (c *Client) RenameTag(ctx context.Context, rename types.TagRename) (*types.RetagResult, error) {
+	return c.retag(ctx, "/v1/tags/rename", nil, rename)
+}
+
+// MergeTags replaces several tags by a single one on every object.
+func (c *Client) MergeTags(ctx context.Context, merge types.TagMerge) (*types.RetagResult, error) {
+	return c.retag(ctx, "/v1/tags/merge", nil, merge)
+}
+
+// ApplyTags adds and removes tags on every object matching filter, which
+// must not be empty.
+func (c *Client) ApplyTags(ctx context.Context, filter types.Filter, apply types.TagApply) (*types.RetagResult, error) {
+	return c.retag(ctx, "/v1/tags/apply", filter.Values(), apply)
+}
+
+func (c *Client) retag(ctx context.Context, route string, query url.Values, in interface{}) (*types.RetagResult, error) {
+	var result types.RetagResult
+
+	if _, err := c.Do(ctx, http.MethodPost, route, query, in, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Target
+
+// Target returns the target of the object id of kind, which is the zero
+// ObjectID when unset. Kits have no target.
+func (c *Client) Target(ctx context.Context, kind Kind, id primitive.ObjectID) (primitive.ObjectID, error) {
+	var target primitive.ObjectID
+
+	_, err := c.Do(ctx, http.MethodGet, route(kind, id, "target"), nil, nil, &target)
+
+	return target, err
+}
+
+// SetTarget sets the target of the object id of kind, which must be a kit,
+// or an assembly for components.
+func (c *Client) SetTarget(ctx context.Context, kind Kind, id, target primitive.ObjectID, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodPost, route(kind, id, "target"), target.Hex(), opts...)
+}
+
+func (c *Client) UnsetTarget(ctx context.Context, kind Kind, id primitive.ObjectID, opts ...Option) (*types.UpdateResult, error) {
+	return c.change(ctx, http.MethodDelete, route(kind, id, "target"), nil, opts...)
+}
+
+// History
+
+// History returns the changes made to the object id of kind, from the oldest.
+func (c *Client) History(ctx context.Context, kind Kind, id primitive.ObjectID) (*types.History, error) {
+	var history types.History
+
+	if _, err := c.Do(ctx, http.MethodGet, route(kind, id, "history"), nil, nil, &history); err != nil {
+		return nil, err
+	}
+
+	return &history, nil
+}
+
+// atValues returns the query parameters of the routes reading objects at a
+// point in time, now if at is zero.
+func atValues(at time.Time) url.Values {
+	if at.IsZero() {
+		return nil
+	}
+
+	return url.Values{"at": {at.Format(time.RFC3339Nano)}}
+}
+
+// KitContents returns the assemblies and components contained in the kit
+// id, as they were at the given time, or now if at is zero.
+func (c *Client) KitContents(ctx context.Context, id primitive.ObjectID, at time.Time) (*types.Contents, error) {
+	var contents types.Contents
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindKit, id, "contents"), atValues(at), nil, &contents); err != nil {
+		return nil, err
+	}
+
+	return &contents, nil
+}
+
+// Snapshot returns every object as they were at the given time, or now if at
+// is zero.
+func (c *Client) Snapshot(ctx context.Context, at time.Time) (*types.Snapshot, error) {
+	var snapshot types.Snapshot
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/snapshot", atValues(at), nil, &snapshot); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// Tree
+
+// Tree returns the objects targeting the object id of kind, which must be a
+// kit or an assembly, nested down to depth, or to the leaves when depth is 0.
+func (c *Client) Tree(ctx context.Context, kind Kind, id primitive.ObjectID, depth int) (*types.Tree, error) {
+	var tree types.Tree
+
+	query := url.Values{}
+	if depth > 0 {
+		query.Set("depth", strconv.Itoa(depth))
+	}
+
+	if _, err := c.Do(ctx, http.MethodGet, route(kind, id, "tree"), query, nil, &tree); err != nil {
+		return nil, err
+	}
+
+	return &tree, nil
+}
+
+// MoveAssembly changes the target of the assembly id to target, or unsets
+// it if target is zero, along with every component it contains. The tree of
+// the moved assembly is returned.
+func (c *Client) MoveAssembly(ctx context.Context, id, target primitive.ObjectID, opts ...Option) (*types.Tree, error) {
+	move := types.Move{}
+	if !target.IsZero() {
+		move.Target = target.Hex()
+	}
+
+	var tree types.Tree
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindAssembly, id, "move"), nil, move, &tree, opts...); err != nil {
+		return nil, err
+	}
+
+	return &tree, nil
+}
+
+// CloneKit copies the kit id with its assemblies and components, and returns
+// the tree of the copy.
+func (c *Client) CloneKit(ctx context.Context, id primitive.ObjectID, clone types.KitClone) (*types.Tree, error) {
+	var tree types.Tree
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindKit, id, "clone"), nil, clone, &tree); err != nil {
+		return nil, err
+	}
+
+	return &tree, nil
+}
+
+// Children returns the objects whose target is the object id of kind.
+func (c *Client) Children(ctx context.Context, kind Kind, id primitive.ObjectID) (*types.Relatives, error) {
+	return c.relatives(ctx, route(kind, id, "children"))
+}
+
+// Ancestors returns the targets of the object id of kind, from its own
+// target up to the kit containing it.
+func (c *Client) Ancestors(ctx context.Context, kind Kind, id primitive.ObjectID) (*types.Relatives, error) {
+	return c.relatives(ctx, route(kind, id, "ancestors"))
+}
+
+func (c *Client) relatives(ctx context.Context, route string) (*types.Relatives, error) {
+	var relatives types.Relatives
+
+	if _, err := c.Do(ctx, http.MethodGet, route, nil, nil, &relatives); err != nil {
+		return nil, err
+	}
+
+	return &relatives, nil
+}
+
+// Locations
+
+// LocationContents returns the locations inside the location id,
+// recursively, then the kits, assemblies and components at any of them.
+func (c *Client) LocationContents(ctx context.Context, id primitive.ObjectID) (*types.Relatives, error) {
+	return c.relatives(ctx, route(KindLocation, id, "contents"))
+}
+
+// Stock
+
+// Stock returns the quantity of the component id, with the movements of its
+// stock.
+func (c *Client) Stock(ctx context.Context, id primitive.ObjectID) (*types.Stock, error) {
+	var stock types.Stock
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindComponent, id, "stock"), nil, nil, &stock); err != nil {
+		return nil, err
+	}
+
+	return &stock, nil
+}
+
+// ChangeStock adds to, or takes from, the quantity of the component id, and
+// returns its stock with the recorded movement. Taking more than the
+// quantity is a conflict, see IsConflict.
+func (c *Client) ChangeStock(ctx context.Context, id primitive.ObjectID, change types.StockChange) (*types.Stock, error) {
+	var stock types.Stock
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindComponent, id, "stock"), nil, change, &stock); err != nil {
+		return nil, err
+	}
+
+	return &stock, nil
+}
+
+// LowStock returns the components whose quantity is at or under their low
+// stock threshold.
+func (c *Client) LowStock(ctx context.Context) (*types.StockLevels, error) {
+	var levels types.StockLevels
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/stock/low", nil, nil, &levels); err != nil {
+		return nil, err
+	}
+
+	return &levels, nil
+}
+
+// Loans
+
+// Checkout lends the kit id, and returns its loan. Lending a kit that is
+// already lent, or over the reservations of someone else without Force, is a
+// conflict, see IsConflict.
+func (c *Client) Checkout(ctx context.Context, id primitive.ObjectID, checkout types.Checkout, opts ...Option) (*types.Loan, error) {
+	var loan types.Loan
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindKit, id, "checkout"), nil, checkout, &loan, opts...); err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// Checkin returns the kit id, and returns its returned loan. Returning a kit
+// that is not lent is a conflict, see IsConflict.
+func (c *Client) Checkin(ctx context.Context, id primitive.ObjectID, checkin types.Checkin) (*types.Loan, error) {
+	var loan types.Loan
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindKit, id, "checkin"), nil, checkin, &loan); err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// KitLoans returns the loans of the kit id, from the oldest check-out.
+func (c *Client) KitLoans(ctx context.Context, id primitive.ObjectID) (*types.Loans, error) {
+	var loans types.Loans
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindKit, id, "loans"), nil, nil, &loans); err != nil {
+		return nil, err
+	}
+
+	return &loans, nil
+}
+
+// Loans returns the loans selected by filter, from the earliest due date.
+func (c *Client) Loans(ctx context.Context, filter types.LoanFilter) (*types.Loans, error) {
+	var loans types.Loans
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/loans", filter.Values(), nil, &loans); err != nil {
+		return nil, err
+	}
+
+	return &loans, nil
+}
+
+// Reservations
+
+// Reserve books the kit id, and returns its reservation. Reserving a kit at a
+// time it is already reserved or lent is a conflict, see IsConflict.
+func (c *Client) Reserve(ctx context.Context, id primitive.ObjectID, reserve types.Reserve) (*types.Reservation, error) {
+	var reservation types.Reservation
+
+	if _, err := c.Do(ctx, http.MethodPost, route(KindKit, id, "reservations"), nil, reserve, &reservation); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// KitReservations returns the reservations of the kit id selected by filter,
+// from the earliest start.
+func (c *Client) KitReservations(ctx context.Context, id primitive.ObjectID, filter types.ReservationFilter) (*types.Reservations, error) {
+	var reservations types.Reservations
+
+	if _, err := c.Do(ctx, http.MethodGet, route(KindKit, id, "reservations"), filter.Values(), nil, &reservations); err != nil {
+		return nil, err
+	}
+
+	return &reservations, nil
+}
+
+// Reservations returns the reservations selected by filter, from the
+// earliest start.
+func (c *Client) Reservations(ctx context.Context, filter types.ReservationFilter) (*types.Reservations, error) {
+	var reservations types.Reservations
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/reservations", filter.Values(), nil, &reservations); err != nil {
+		return nil, err
+	}
+
+	return &reservations, nil
+}
+
+// ReservationsCalendar returns the reservations selected by filter as an
+// iCalendar feed.
+func (c *Client) ReservationsCalendar(ctx context.Context, filter types.ReservationFilter) ([]byte, error) {
+	var feed []byte
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/reservations/calendar", filter.Values(), nil, &feed); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// CancelReservation deletes the reservation id.
+func (c *Client) CancelReservation(ctx context.Context, id primitive.ObjectID) (*types.DeleteResult, error) {
+	var result types.DeleteResult
+
+	if _, err := c.Do(ctx, http.MethodDelete, "/v1/reservation/"+id.Hex(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Availability returns the kits selected by filter that are neither reserved
+// nor lent at any time between start and end.
+func (c *Client) Availability(ctx context.Context, filter types.Filter, start, end time.Time) (*types.Availability, error) {
+	var availability types.Availability
+
+	query := filter.Values()
+	query.Set("start", start.Format(time.RFC3339))
+	query.Set("end", end.Format(time.RFC3339))
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/availability", query, nil, &availability); err != nil {
+		return nil, err
+	}
+
+	return &availability, nil
+}
+
+// Schemas
+
+// Schema returns the schema of the custom fields of kind.
+func (c *Client) Schema(ctx context.Context, kind Kind) (*types.Schema, error) {
+	var schema types.Schema
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/schema/"+string(kind), nil, nil, &schema); err != nil {
+		return nil, err
+	}
+
+	return &schema, nil
+}
+
+// SetSchema replaces the schema of the custom fields of kind, and returns it.
+func (c *Client) SetSchema(ctx context.Context, kind Kind, schema types.Schema) (*types.Schema, error) {
+	var set types.Schema
+
+	if _, err := c.Do(ctx, http.MethodPut, "/v1/schema/"+string(kind), nil, schema, &set); err != nil {
+		return nil, err
+	}
+
+	return &set, nil
+}
+
+// Duplicates
+
+// Duplicates returns the groups of objects whose values of a key, such as
+// their name, only differ by case or whitespace.
+func (c *Client) Duplicates(ctx context.Context, filter types.DuplicateFilter) (*types.Duplicates, error) {
+	var duplicates types.Duplicates
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/duplicates", filter.Values(), nil, &duplicates); err != nil {
+		return nil, err
+	}
+
+	return &duplicates, nil
+}
+
+// Trash
+
+func (c *Client) Trash(ctx context.Context) (*types.Trash, error) {
+	var trash types.Trash
+
+	if _, err := c.Do(ctx, http.MethodGet, "/v1/trash", nil, nil, &trash); err != nil {
+		return nil, err
+	}
+
+	return &trash, nil
+}
+
+// PurgeTrash permanently deletes the objects that stayed in the trash longer
+// than the retention of the server, or every object if all is true.
+func (c *Client) PurgeTrash(ctx context.Context, all bool) (*types.DeleteResult, error) {
+	var result types.DeleteResult
+
+	query := url.Values{"all": {fmt.Sprint(all)}}
+
+	if _, err := c.Do(ctx, http.MethodDelete, "/v1/trash", query, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Restore restores the object id of kind from the trash, along with the
+// objects deleted with it, and returns the object.
+func (c *Client) Restore(ctx context.Context, kind Kind, id primitive.ObjectID) (*types.Node, error) {
+	var restored types.Node
+
+	if _, err := c.Do(ctx, http.MethodPost, route(kind, id, "restore"), nil, nil, &restored); err != nil {
+		return nil, err
+	}
+
+	restored.Kind = string(kind)
+
+	return &restored, nil
+}
@@ -1,11 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 
-	"codeberg.org/haulproject/haul/api"
 	"github.com/spf13/cobra"
 )
 
@@ -14,12 +13,17 @@ var apiCmd = &cobra.Command{
 	Use:   "api",
 	Short: "Obtain the api routes on the haul instance",
 	Run: func(cmd *cobra.Command, args []string) {
-		result, err := api.Call(http.MethodGet, "/v1")
+		result, err := newClient().Routes(context.Background())
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		fmt.Println(string(result))
+		message, err := json.Marshal(result)
+		if err != nil {
+			fatal(err)
+		}
+
+		fmt.Println(string(message))
 	},
 }
 
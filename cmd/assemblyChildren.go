@@ -0,0 +1,22 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// assemblyChildrenCmd represents the assemblyChildren command
+var assemblyChildrenCmd = &cobra.Command{
+	Use:   "children OBJECT_ID",
+	Short: "Prints the components whose target is assembly identified by OBJECT_ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printChildren(client.KindAssembly, args[0])
+	},
+}
+
+func init() {
+	assemblyCmd.AddCommand(assemblyChildrenCmd)
+}
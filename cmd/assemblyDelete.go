@@ -3,11 +3,8 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
-	"net/http"
-
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +13,39 @@ var assemblyDeleteCmd = &cobra.Command{
 	Use:     "delete OBJECT_ID...",
 	Aliases: []string{"rm", "remove", "del"},
 	Short:   "Deletes assemblies identified by one or more OBJECT_ID",
-	Args:    cobra.MinimumNArgs(1),
+	Long: `Deletes assemblies identified by one or more OBJECT_ID.
+
+By default, an assembly that is still the target of components is not deleted, and the objects targeting it are listed instead.
+
+Use --orphan to clear the target of these objects before deleting the assembly, or --cascade to delete them as well, along with the objects targeting them in turn.
+
+Deleted objects go to the trash, from which they can be restored with 'haul trash restore'.`,
+	Example: `Delete an assembly along with every object it contains
+
+    $ haul assembly delete --cascade 64212ede8e7046c7a1e88557`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		for _, arg := range args {
-			result, err := api.Call(http.MethodDelete, fmt.Sprintf("/v1/assembly/%s", arg))
-			if err != nil {
-				log.Fatal(err)
-			}
-			fmt.Println(string(result))
+		onReferenced := types.OnReferencedReject
+
+		if cascade, _ := cmd.Flags().GetBool("cascade"); cascade {
+			onReferenced = types.OnReferencedCascade
 		}
+
+		if orphan, _ := cmd.Flags().GetBool("orphan"); orphan {
+			onReferenced = types.OnReferencedOrphan
+		}
+
+		deleteObjects(client.KindAssembly, args, onReferenced, forceOptions(cmd)...)
 	},
 }
 
 func init() {
 	assemblyCmd.AddCommand(assemblyDeleteCmd)
+
+	addForceFlag(assemblyDeleteCmd)
+
+	assemblyDeleteCmd.Flags().Bool("cascade", false, "Also delete the objects targeting this assembly, recursively")
+	assemblyDeleteCmd.Flags().Bool("orphan", false, "Clear the target of the objects targeting this assembly")
+
+	assemblyDeleteCmd.MarkFlagsMutuallyExclusive("cascade", "orphan")
 }
@@ -3,11 +3,8 @@
 package cmd
 
 import (
-	"encoding/json"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
@@ -17,36 +14,56 @@ import (
 var assemblyListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
-	Short:   "Prints values of all assemblies",
-	Args:    cobra.ExactArgs(0),
+	Short:   "Prints values of assemblies, optionally filtered and sorted",
+	Example: `List assemblies tagged "type=workstation" and named "Workstation 1"
+
+    $ haul assembly list --filter tag=type=workstation --filter name="Workstation 1"
+
+List assemblies whose name starts with "Workstation", or that have no target
+
+    $ haul assembly list --any --filter name_prefix=Workstation --filter untargeted
+
+List every assemblies, following all pages, sorted by status then by name in reverse order
+
+    $ haul assembly list --all --sort status,-name`,
+	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		assemblies_bytes, err := api.Call(http.MethodGet, "/v1/assembly")
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var assemblies types.AssembliesWithID
+		filter, listOptions, all, err := getListFlags(cmd)
+		if err != nil {
+			fatal(err)
+		}
 
-		if err := json.Unmarshal(assemblies_bytes, &assemblies.AssembliesWithID); err != nil {
-			log.Fatal(err)
+		var assemblies *types.AssembliesWithID
+
+		if all {
+			assemblies, err = newClient().ListAllAssemblies(context.Background(), filter, listOptions)
+		} else {
+			assemblies, err = newClient().ListAssemblies(context.Background(), filter, listOptions)
+		}
+		if err != nil {
+			fatal(err)
 		}
 
-		err = client.OutputObject(&assemblies)
+		err = client.OutputObject(assemblies)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
+
+		printNextPage(assemblies.Page)
 	},
 }
 
 func init() {
 	assemblyCmd.AddCommand(assemblyListCmd)
+
+	addListFlags(assemblyListCmd)
 }
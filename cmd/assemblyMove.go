@@ -0,0 +1,67 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// assemblyMoveCmd represents the assemblyMove command
+var assemblyMoveCmd = &cobra.Command{
+	Use:   "move OBJECT_ID KIT_ID",
+	Short: "Moves assembly identified by OBJECT_ID, with its components, to kit identified by KIT_ID",
+	Long: `Moves assembly identified by OBJECT_ID, with its components, to kit identified by KIT_ID, and prints its tree.
+
+With --detach instead of KIT_ID, the assembly is taken out of its kit.`,
+	Example: `Move a PC with its components to another kit
+
+    $ haul assembly move 64212ede8e7046c7a1e88557 64212f0c8e7046c7a1e8855a`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		detach, err := cmd.Flags().GetBool("detach")
+		if err != nil {
+			fatal(err)
+		}
+
+		if detach == (len(args) == 2) {
+			fatal(errors.New("Either KIT_ID or --detach is required"))
+		}
+
+		var target primitive.ObjectID
+		if !detach {
+			target = objectID(args[1])
+		}
+
+		opts := forceOptions(cmd)
+
+		if cmd.Flags().Changed("if-match") {
+			version, err := cmd.Flags().GetInt64("if-match")
+			if err != nil {
+				fatal(err)
+			}
+
+			opts = append(opts, client.IfMatch(version))
+		}
+
+		tree, err := newClient().MoveAssembly(context.Background(), objectID(args[0]), target, opts...)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(tree)
+	},
+}
+
+func init() {
+	assemblyCmd.AddCommand(assemblyMoveCmd)
+
+	addIfMatchFlag(assemblyMoveCmd)
+	addForceFlag(assemblyMoveCmd)
+
+	assemblyMoveCmd.Flags().Bool("detach", false, "Take the assembly out of its kit instead")
+}
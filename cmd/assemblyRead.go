@@ -3,14 +3,9 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
-	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -23,28 +18,21 @@ var assemblyReadCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		assembly_bytes, err := api.Call(http.MethodGet, fmt.Sprintf("/v1/assembly/%s", args[0]))
+		assembly, err := newClient().ReadAssembly(context.Background(), objectID(args[0]))
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var assembly types.AssemblyWithID
-
-		err = json.Unmarshal(assembly_bytes, &assembly)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		err = client.OutputObject(&assembly)
+		err = client.OutputObject(assembly)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 	},
 }
@@ -0,0 +1,27 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// assemblyTreeCmd represents the assemblyTree command
+var assemblyTreeCmd = &cobra.Command{
+	Use:   "tree OBJECT_ID",
+	Short: "Prints the components of assembly identified by OBJECT_ID as a tree",
+	Long: `Prints the components of assembly identified by OBJECT_ID as a tree.
+
+Use '--output json' for the nested structure.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printTree(cmd, client.KindAssembly, args[0])
+	},
+}
+
+func init() {
+	assemblyCmd.AddCommand(assemblyTreeCmd)
+
+	addTreeFlags(assemblyTreeCmd)
+}
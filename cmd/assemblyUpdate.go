@@ -3,12 +3,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
 
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -30,35 +29,35 @@ To empty a field, provide the zero value for the field. Note that "name" cannot
 
 		var assembly map[string]interface{}
 
-		id := args[0]
+		id := objectID(args[0])
 
 		update, err := cmd.Flags().GetString("data")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		err = json.Unmarshal([]byte(update), &assembly)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		currentAssembly, err := json.Marshal(assembly)
+		result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+			return newClient().Update(context.Background(), client.KindAssembly, id, assembly, opts...)
+		})
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		result, err := api.CallWithData(http.MethodPut, fmt.Sprintf("/v1/assembly/%s", id), currentAssembly)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fmt.Printf("%s", result)
+		outputObject(result)
 	},
 }
 
 func init() {
 	assemblyCmd.AddCommand(assemblyUpdateCmd)
 
+	addIfMatchFlag(assemblyUpdateCmd)
+	addForceFlag(assemblyUpdateCmd)
+
 	assemblyUpdateCmd.Flags().String("data", "", "Data to use in the update, in JSON format")
 	assemblyUpdateCmd.MarkFlagRequired("data")
 }
@@ -0,0 +1,22 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// assemblyWhereCmd represents the assemblyWhere command
+var assemblyWhereCmd = &cobra.Command{
+	Use:   "where OBJECT_ID",
+	Short: "Prints the kit containing assembly identified by OBJECT_ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printAncestors(client.KindAssembly, args[0])
+	},
+}
+
+func init() {
+	assemblyCmd.AddCommand(assemblyWhereCmd)
+}
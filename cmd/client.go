@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"codeberg.org/haulproject/haul/cli"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newClient returns a client of the api configured by the 'api.*' settings.
+func newClient() *client.Client {
+	c := client.New(fmt.Sprintf("%s://%s:%d",
+		viper.GetString("api.protocol"),
+		viper.GetString("api.host"),
+		viper.GetInt("api.port"),
+	), viper.GetString("api.key"))
+
+	c.Timeout = viper.GetDuration("api.timeout")
+
+	return c
+}
+
+// objectID returns the ObjectID given as argument, or exits if it is
+// invalid.
+func objectID(arg string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(arg)
+	if err != nil {
+		log.Printf("Invalid ObjectID '%s': %s", arg, err)
+		os.Exit(exitInvalid)
+	}
+
+	return id
+}
+
+// parseAt returns the time given to an --at flag, as RFC 3339 or as a date,
+// or the zero time for now when empty.
+func parseAt(at string) (time.Time, error) {
+	if at == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time '%s', must be RFC 3339 (e.g. 2026-05-01T00:00:00Z) or a date (e.g. 2026-05-01)", at)
+	}
+
+	return t, nil
+}
+
+// parseStart returns the time given to a --start or --from flag, as RFC 3339
+// or as a date, which starts with that day in the local time zone.
+func parseStart(value string) (time.Time, error) {
+	t, _, err := parseDay(value)
+	return t, err
+}
+
+// parseDue returns the time given to a --due, --end or --to flag, as RFC 3339
+// or as a date, which ends with that day in the local time zone.
+func parseDue(value string) (time.Time, error) {
+	t, date, err := parseDay(value)
+	if date {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	return t, err
+}
+
+// parseDay returns the time given as RFC 3339, or the start of the day given
+// as a date in the local time zone, and whether it was a date.
+func parseDay(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Invalid time '%s', must be RFC 3339 (e.g. 2026-11-01T17:00:00Z) or a date (e.g. 2026-11-01)", value)
+	}
+
+	return t, true, nil
+}
+
+// outputObject prints object in the style selected by --output, or exits on
+// error.
+func outputObject(object types.TabbyPrinter) {
+	output := cli.New()
+
+	style, err := rootCmd.PersistentFlags().GetString("output")
+	if err != nil {
+		fatal(err)
+	}
+
+	output.OutputStyle = style
+
+	if err := output.OutputObject(object); err != nil {
+		fatal(err)
+	}
+}
+
+// deleteObjects deletes the objects of kind identified by ids, and prints the
+// result of each deletion. The objects still targeting an object that could
+// not be deleted are printed before exiting.
+func deleteObjects(kind client.Kind, ids []string, onReferenced string, opts ...client.Option) {
+	api := newClient()
+
+	for _, arg := range ids {
+		result, err := api.Delete(context.Background(), kind, objectID(arg), onReferenced, opts...)
+
+		var e *client.Error
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict {
+			var referenced types.ReferencedDetails
+
+			if e.Decode(&referenced) == nil {
+				outputObject(referenced)
+			}
+		}
+
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	}
+}
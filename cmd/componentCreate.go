@@ -3,12 +3,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"log"
-	"net/http"
 	"os"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
@@ -23,9 +22,11 @@ var componentCreateCmd = &cobra.Command{
 
 	Multiple components can be created by giving splitting them as individual arguments.
 
-The "name" field must be non-blank, but its value can be any string. Examples of "name" include a description of the component, or something like a serial number, mac address, or other identifier. It currently does not need to be unique in the database.
+The "name" field must be non-blank, but its value can be any string. Examples of "name" include a description of the component, or something like a serial number, mac address, or other identifier. It does not need to be unique, unless the server makes the name, or a tag key such as "serial=", unique among components (see 'server.unique').
 
-The "tags" field is non-mandatory. It can however be used to convey more detailed information about the component.`,
+The "tags" field is non-mandatory. It can however be used to convey more detailed information about the component.
+
+The "fields" field holds the custom fields of the component, as defined by 'haul schema', e.g. { "serial": "SN-1234" }.`,
 
 	Example: `Create new 8gb RAM stick, with the "name" field used for a simple description
 
@@ -56,14 +57,9 @@ Create a new set of speakers without any tags
 			os.Exit(1)
 		}
 
-		components_bytes, err := json.Marshal(components.Components)
-		if err != nil {
-			log.Fatal("json.Marshal:", err)
-		}
-
-		result, err := api.CallWithDataB(http.MethodPost, "/v1/component", components_bytes)
+		result, err := newClient().CreateComponents(context.Background(), components.Components)
 		if err != nil {
-			log.Fatal("api.CallWithDataB:", err)
+			fatal(err)
 		}
 
 		// Using cli object
@@ -71,19 +67,12 @@ Create a new set of speakers without any tags
 
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var result_object types.InsertResult
-
-		err = json.Unmarshal(result, &result_object)
-		if err != nil {
-			log.Fatal("Error unmarshalling POST /v1/component:", err, "\n")
-		}
-
-		err = client.OutputObject(result_object)
+		err = client.OutputObject(result)
 		if err != nil {
 			log.Fatal("Error outputting object:", err)
 		}
@@ -3,11 +3,7 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
-	"net/http"
-
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +12,17 @@ var componentDeleteCmd = &cobra.Command{
 	Use:     "delete OBJECT_ID...",
 	Aliases: []string{"rm", "remove", "del"},
 	Short:   "Deletes components identified one or more by OBJECT_ID",
-	Args:    cobra.MinimumNArgs(1),
+	Long: `Deletes components identified one or more by OBJECT_ID.
+
+Deleted components go to the trash, from which they can be restored with 'haul trash restore'.`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		for _, arg := range args {
-			result, err := api.Call(http.MethodDelete, fmt.Sprintf("/v1/component/%s", arg))
-			if err != nil {
-				log.Fatal(err)
-			}
-			fmt.Println(string(result))
-		}
+		deleteObjects(client.KindComponent, args, "", forceOptions(cmd)...)
 	},
 }
 
 func init() {
 	componentCmd.AddCommand(componentDeleteCmd)
+
+	addForceFlag(componentDeleteCmd)
 }
@@ -0,0 +1,45 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/cli"
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// componentHistoryCmd represents the componentHistory command
+var componentHistoryCmd = &cobra.Command{
+	Use:   "history OBJECT_ID",
+	Short: "Prints the changes made to component identified by OBJECT_ID",
+	Long: `Prints the changes made to component identified by OBJECT_ID, from oldest to newest, with the API key that made them.
+
+The history of deleted components is kept.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out := cli.New()
+
+		history, err := newClient().History(context.Background(), client.KindComponent, objectID(args[0]))
+		if err != nil {
+			fatal(err)
+		}
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		out.OutputStyle = output
+
+		err = out.OutputObject(history)
+		if err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	componentCmd.AddCommand(componentHistoryCmd)
+}
@@ -3,11 +3,8 @@
 package cmd
 
 import (
-	"encoding/json"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
@@ -17,39 +14,59 @@ import (
 var componentListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
-	Short:   "Prints values of all components",
-	Args:    cobra.ExactArgs(0),
+	Short:   "Prints values of components, optionally filtered and sorted",
+	Example: `List components tagged "type=ram" and named "Generic 8gb RAM"
+
+    $ haul component list --filter tag=type=ram --filter name="Generic 8gb RAM"
+
+List components whose name starts with "Generic", or that have no target
+
+    $ haul component list --any --filter name_prefix=Generic --filter untargeted
+
+List every components, following all pages, sorted by status then by name in reverse order
+
+    $ haul component list --all --sort status,-name`,
+	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		components_bytes, err := api.Call(http.MethodGet, "/v1/component")
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var components types.ComponentsWithID
+		filter, listOptions, all, err := getListFlags(cmd)
+		if err != nil {
+			fatal(err)
+		}
 
-		if err := json.Unmarshal(components_bytes, &components.ComponentsWithID); err != nil {
-			log.Fatal(err)
+		var components *types.ComponentsWithID
+
+		if all {
+			components, err = newClient().ListAllComponents(context.Background(), filter, listOptions)
+		} else {
+			components, err = newClient().ListComponents(context.Background(), filter, listOptions)
+		}
+		if err != nil {
+			fatal(err)
 		}
 
-		err = client.OutputObject(&components)
+		err = client.OutputObject(components)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
+
+		printNextPage(components.Page)
 	},
 }
 
 func init() {
 	componentCmd.AddCommand(componentListCmd)
 
+	addListFlags(componentListCmd)
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -3,14 +3,9 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
-	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -23,28 +18,21 @@ var componentReadCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		component_bytes, err := api.Call(http.MethodGet, fmt.Sprintf("/v1/component/%s", args[0]))
+		component, err := newClient().ReadComponent(context.Background(), objectID(args[0]))
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var component types.ComponentWithID
-
-		err = json.Unmarshal(component_bytes, &component)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		err = client.OutputObject(&component)
+		err = client.OutputObject(component)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 	},
 }
@@ -0,0 +1,30 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// componentStatusCmd represents the componentStatus command
+var componentStatusCmd = &cobra.Command{
+	Use:   "status OBJECT_ID STATUS",
+	Short: "Changes the status of component identified by OBJECT_ID to STATUS",
+	Long: `Changes the status of component identified by OBJECT_ID to STATUS.
+
+The status and the transition from the current status are validated against the statuses allowed by the server, see 'haul statuses'.`,
+	Example: `Send a broken component back to the manufacturer
+
+    $ haul component status 64212ede8e7046c7a1e88557 rma`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setStatus(cmd, client.KindComponent, args[0], args[1])
+	},
+}
+
+func init() {
+	componentCmd.AddCommand(componentStatusCmd)
+
+	addIfMatchFlag(componentStatusCmd)
+}
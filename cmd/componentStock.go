@@ -0,0 +1,76 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// componentStockCmd represents the componentStock command
+var componentStockCmd = &cobra.Command{
+	Use:   "stock OBJECT_ID",
+	Short: "Prints or changes the quantity of component identified by OBJECT_ID",
+	Long: `Prints the quantity of component identified by OBJECT_ID, with the movements of its stock.
+
+With --add or --take, the quantity is changed and the movement is recorded. Components without a quantity start from 0 when added to. Taking more than the quantity fails.
+
+The unit and the low stock threshold are set by updating the component, e.g. '--data '{ "unit": "g", "low_stock": 20 }''.`,
+	Example: `Stock up on screws, then take some of them
+
+    $ haul component stock 64212ede8e7046c7a1e88557 --add 50 --reason "order #1234"
+    $ haul component stock 64212ede8e7046c7a1e88557 --take 3`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var change types.StockChange
+		var err error
+
+		if change.Add, err = cmd.Flags().GetFloat64("add"); err != nil {
+			fatal(err)
+		}
+
+		if change.Take, err = cmd.Flags().GetFloat64("take"); err != nil {
+			fatal(err)
+		}
+
+		if change.Reason, err = cmd.Flags().GetString("reason"); err != nil {
+			fatal(err)
+		}
+
+		api := newClient()
+		id := objectID(args[0])
+
+		if change.Add == 0 && change.Take == 0 {
+			if change.Reason != "" {
+				fatal(errors.New("--reason needs --add or --take"))
+			}
+
+			stock, err := api.Stock(context.Background(), id)
+			if err != nil {
+				fatal(err)
+			}
+
+			outputObject(stock)
+			return
+		}
+
+		stock, err := api.ChangeStock(context.Background(), id, change)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(stock)
+	},
+}
+
+func init() {
+	componentCmd.AddCommand(componentStockCmd)
+
+	componentStockCmd.Flags().Float64("add", 0, "Quantity added to the stock")
+	componentStockCmd.Flags().Float64("take", 0, "Quantity taken from the stock")
+	componentStockCmd.Flags().String("reason", "", "Reason of the change, recorded with it")
+	componentStockCmd.MarkFlagsMutuallyExclusive("add", "take")
+}
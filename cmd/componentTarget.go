@@ -3,13 +3,13 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -28,56 +28,57 @@ var componentTargetCmd = &cobra.Command{
 		clear, _ = cmd.Flags().GetBool("clear")
 		set, _ = cmd.Flags().GetString("set")
 
+		api := newClient()
+		ctx := context.Background()
+		id := objectID(args[0])
+
 		if clear {
 			// Clear target
 			log.Println("Clearing target")
 
-			result, err := api.Call(http.MethodDelete, fmt.Sprintf("/v1/component/%s/target", args[0]))
+			result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+				return api.UnsetTarget(ctx, client.KindComponent, id, opts...)
+			})
 			if err != nil {
-				log.Fatalf("api.CallWithData: %s\n", err)
+				fatal(err)
 			}
 
-			log.Println(string(result))
+			outputObject(result)
 
 			os.Exit(0)
 		}
 
 		if set != "" {
 			// Add some target
-			data, err := json.Marshal(set)
-			if err != nil {
-				log.Fatalf("json.Marshal: %s", err)
-			}
+			target := objectID(set)
 
-			result, err := api.CallWithData(http.MethodPost, fmt.Sprintf("/v1/component/%s/target", args[0]), data)
+			result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+				return api.SetTarget(ctx, client.KindComponent, id, target, opts...)
+			})
 			if err != nil {
-				log.Fatalf("api.Call: %s\n", err)
+				fatal(err)
 			}
 
-			log.Println(result)
+			outputObject(result)
 			os.Exit(0)
 		}
 
 		// Show target
-		result, err := api.Call(http.MethodGet, fmt.Sprintf("/v1/component/%s/target", args[0]))
-		if err != nil {
-			log.Fatalf("api.Call: %s\n", err)
-		}
-
-		var target string
-
-		err = json.Unmarshal(result, &target)
+		target, err := api.Target(ctx, client.KindComponent, id)
 		if err != nil {
-			log.Fatalf("json.Unmarshal: %s\nresult: %s", err, string(result))
+			fatal(err)
 		}
 
-		fmt.Println(target)
+		fmt.Println(target.Hex())
 	},
 }
 
 func init() {
 	componentCmd.AddCommand(componentTargetCmd)
 
+	addIfMatchFlag(componentTargetCmd)
+	addForceFlag(componentTargetCmd)
+
 	componentTargetCmd.Flags().String("set", "", "Set this object's target object")
 
 	componentTargetCmd.Flags().Bool("clear", false, "If set, will clear target for this object")
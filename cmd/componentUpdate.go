@@ -3,12 +3,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
 
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -30,35 +29,35 @@ To empty a field, provide the zero value for the field. Note that "name" cannot
 
 		var component map[string]interface{}
 
-		id := args[0]
+		id := objectID(args[0])
 
 		update, err := cmd.Flags().GetString("data")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		err = json.Unmarshal([]byte(update), &component)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		currentComponent, err := json.Marshal(component)
+		result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+			return newClient().Update(context.Background(), client.KindComponent, id, component, opts...)
+		})
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		result, err := api.CallWithData(http.MethodPut, fmt.Sprintf("/v1/component/%s", id), currentComponent)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fmt.Printf("%s", result)
+		outputObject(result)
 	},
 }
 
 func init() {
 	componentCmd.AddCommand(componentUpdateCmd)
 
+	addIfMatchFlag(componentUpdateCmd)
+	addForceFlag(componentUpdateCmd)
+
 	componentUpdateCmd.Flags().String("data", "", "Data to use in the update, in JSON format")
 	componentUpdateCmd.MarkFlagRequired("data")
 }
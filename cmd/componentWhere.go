@@ -0,0 +1,22 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// componentWhereCmd represents the componentWhere command
+var componentWhereCmd = &cobra.Command{
+	Use:   "where OBJECT_ID",
+	Short: "Prints the assembly and kit containing component identified by OBJECT_ID, from its target up",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printAncestors(client.KindComponent, args[0])
+	},
+}
+
+func init() {
+	componentCmd.AddCommand(componentWhereCmd)
+}
@@ -0,0 +1,17 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find problems in the objects of the database",
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
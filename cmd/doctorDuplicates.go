@@ -0,0 +1,39 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// doctorDuplicatesCmd represents the doctorDuplicates command
+var doctorDuplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Prints the objects whose names or tag values only differ by case or whitespace",
+	Long: `Prints the groups of objects, not in the trash, whose values of a key only differ by case or whitespace, e.g. "SN 1234 " and "sn1234".
+
+By default, the name of every kind is searched, along with the keys made unique by the server (see 'server.unique'). Such duplicates must be fixed before the server can make a key unique.`,
+	Example: `haul doctor duplicates --kind component --key name --key tag.serial`,
+	Args:    cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		kinds, _ := cmd.Flags().GetStringArray("kind")
+		keys, _ := cmd.Flags().GetStringArray("key")
+
+		duplicates, err := newClient().Duplicates(context.Background(), types.DuplicateFilter{Kinds: kinds, Keys: keys})
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(duplicates)
+	},
+}
+
+func init() {
+	doctorCmd.AddCommand(doctorDuplicatesCmd)
+
+	doctorDuplicatesCmd.Flags().StringArray("kind", nil, "Kind of objects to search { component | assembly | kit | location }, can be repeated")
+	doctorDuplicatesCmd.Flags().StringArray("key", nil, "Key to search, 'name' or 'tag.<key>' such as 'tag.serial', can be repeated")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"codeberg.org/haulproject/haul/client"
+)
+
+// Exit status of the commands, by kind of error, see exitCode.
+const (
+	exitError        = 1 // Any other error, e.g. invalid arguments
+	exitUnreachable  = 2 // The server could not be reached
+	exitServerError  = 3 // The server or its database failed
+	exitInvalid      = 4 // The request was refused as invalid
+	exitUnauthorized = 5 // The key is missing or invalid
+	exitNotFound     = 6 // The object does not exist
+	exitConflict     = 7 // The change conflicts with the state of the objects
+)
+
+// exitCode returns the exit status of a command failing with err.
+func exitCode(err error) int {
+	switch {
+	case client.IsUnreachable(err):
+		return exitUnreachable
+	case client.IsServerError(err):
+		return exitServerError
+	case client.IsInvalid(err):
+		return exitInvalid
+	case client.IsUnauthorized(err):
+		return exitUnauthorized
+	case client.IsNotFound(err):
+		return exitNotFound
+	case client.IsConflict(err), client.IsPreconditionFailed(err):
+		return exitConflict
+	}
+
+	return exitError
+}
+
+// fatal prints err and exits with the status of its kind, see exitCode.
+func fatal(err error) {
+	log.Println(err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// addFilterFlags adds the flags read by getFilter to a list command.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("filter", nil, `Only list objects matching KEY=VALUE. Can be repeated.
+Valid keys are { name | name_prefix | name_regex | status | tag | not_tag | target | untargeted | location | tag.<key> | field.<name>[.min|.max] },
+e.g. 'tag.type=ram' lists objects tagged 'type=ram', 'tag.type=*' objects with any 'type=' tag,
+and 'field.price.max=100' objects whose custom field 'price' is at most 100, see 'haul schema'`)
+	cmd.Flags().Bool("any", false, "List objects matching any of the filters, instead of all of them")
+}
+
+// getFilter returns the types.Filter described by the flags added by
+// addFilterFlags.
+func getFilter(cmd *cobra.Command) (types.Filter, error) {
+	var filter types.Filter
+
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return filter, err
+	}
+
+	for _, f := range filters {
+		key, value, found := strings.Cut(f, "=")
+
+		switch key {
+		case "name":
+			filter.Name = value
+		case "name_prefix":
+			filter.NamePrefix = value
+		case "name_regex":
+			filter.NameRegex = value
+		case "status":
+			filter.Status = append(filter.Status, value)
+		case "tag":
+			filter.Tags = append(filter.Tags, value)
+		case "not_tag":
+			filter.NotTags = append(filter.NotTags, value)
+		case "target":
+			filter.Target = value
+		case "untargeted":
+			filter.Untargeted = !found || value == "true"
+		case "location":
+			filter.Location = value
+		default:
+			switch {
+			case strings.HasPrefix(key, types.TagValuesPrefix):
+				if filter.TagValues == nil {
+					filter.TagValues = map[string][]string{}
+				}
+
+				tagKey := strings.TrimPrefix(key, types.TagValuesPrefix)
+				filter.TagValues[tagKey] = append(filter.TagValues[tagKey], value)
+			case strings.HasPrefix(key, types.FieldValuesPrefix):
+				if filter.FieldValues == nil {
+					filter.FieldValues = map[string][]string{}
+				}
+
+				fieldKey := strings.TrimPrefix(key, types.FieldValuesPrefix)
+				filter.FieldValues[fieldKey] = append(filter.FieldValues[fieldKey], value)
+			default:
+				return filter, fmt.Errorf("Unknown filter key '%s' in '%s'", key, f)
+			}
+		}
+	}
+
+	any, err := cmd.Flags().GetBool("any")
+	if err != nil {
+		return filter, err
+	}
+
+	if any {
+		filter.Match = types.FilterMatchAny
+	}
+
+	return filter, nil
+}
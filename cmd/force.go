@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// addForceFlag adds the flag read by forceOptions to a command changing
+// objects that may be in a lent kit.
+func addForceFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("force", false, "Apply the change even if the object is a checked out kit, or is in one")
+}
+
+// forceOptions returns the options of a request honouring the flag added by
+// addForceFlag, if any.
+func forceOptions(cmd *cobra.Command) []client.Option {
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		return []client.Option{client.Force()}
+	}
+
+	return nil
+}
@@ -1,14 +1,11 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/graph"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/goccy/go-graphviz"
@@ -19,11 +16,18 @@ import (
 var graphCmd = &cobra.Command{
 	Use:   "graph",
 	Short: "Produce a graphviz graph of objects",
+	Long: `Produce a graphviz graph of objects, with an edge from each object to its target.
+
+Locations are drawn as boxes around the objects they contain, nested from sites down to shelves.`,
 	Example: `
 Export the haul graph to a file called 'graph.svg':
 
   $ haul graph --format svg --file graph.svg
 
+Export the haul graph as it was on May 1st 2026
+
+  $ haul graph --format svg --file graph.svg --at 2026-05-01
+
 Export the haul graph in dot format to stdout
 
   $ haul graph --format dot
@@ -35,7 +39,7 @@ or, with default settings:
 	Run: func(cmd *cobra.Command, args []string) {
 		format, err := cmd.Flags().GetString("format")
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 
 		if format == "" {
@@ -46,47 +50,70 @@ or, with default settings:
 
 		filepath, err := cmd.Flags().GetString("file")
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 
 		var (
 			components types.ComponentsWithID
 			assemblies types.AssembliesWithID
 			kits       types.KitsWithID
+			locations  types.LocationsWithID
 		)
 
-		// By default, show all objects in the graph
-
-		components_bytes, err := api.Call(http.MethodGet, "/v1/component")
-		if err != nil {
-			log.Fatal("Error:", err)
-		}
-
-		if err = json.Unmarshal(components_bytes, &components.ComponentsWithID); err != nil {
-			log.Fatal("Error:", err)
-		}
-
-		assemblies_bytes, err := api.Call(http.MethodGet, "/v1/assembly")
+		at, err := cmd.Flags().GetString("at")
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 
-		if err = json.Unmarshal(assemblies_bytes, &assemblies.AssembliesWithID); err != nil {
-			log.Fatal("Error:", err)
-		}
-
-		kits_bytes, err := api.Call(http.MethodGet, "/v1/kit")
+		t, err := parseAt(at)
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 
-		if err = json.Unmarshal(kits_bytes, &kits.KitsWithID); err != nil {
-			log.Fatal("Error:", err)
+		api := newClient()
+		ctx := context.Background()
+
+		if !t.IsZero() {
+			// Objects as they were at that time
+
+			snapshot, err := api.Snapshot(ctx, t)
+			if err != nil {
+				fatal(err)
+			}
+
+			components.ComponentsWithID = snapshot.Components
+			assemblies.AssembliesWithID = snapshot.Assemblies
+			kits.KitsWithID = snapshot.Kits
+			locations.LocationsWithID = snapshot.Locations
+		} else {
+			// By default, show all objects in the graph
+
+			allComponents, err := api.ListAllComponents(ctx, types.Filter{}, types.ListOptions{})
+			if err != nil {
+				fatal(err)
+			}
+
+			allAssemblies, err := api.ListAllAssemblies(ctx, types.Filter{}, types.ListOptions{})
+			if err != nil {
+				fatal(err)
+			}
+
+			allKits, err := api.ListAllKits(ctx, types.Filter{}, types.ListOptions{})
+			if err != nil {
+				fatal(err)
+			}
+
+			allLocations, err := api.ListAllLocations(ctx, types.Filter{}, types.ListOptions{})
+			if err != nil {
+				fatal(err)
+			}
+
+			components, assemblies, kits, locations = *allComponents, *allAssemblies, *allKits, *allLocations
 		}
 
-		buf, err := graph.GetGraph(graphviz.Format(format), components, assemblies, kits)
+		buf, err := graph.GetGraph(graphviz.Format(format), components, assemblies, kits, locations)
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 
 		if filepath == "" {
@@ -96,7 +123,7 @@ or, with default settings:
 
 		file, err := os.Create(filepath)
 		if err != nil {
-			log.Fatal("Error:", err)
+			fatal(err)
 		}
 		defer file.Close()
 
@@ -110,4 +137,6 @@ func init() {
 	graphCmd.Flags().String("format", "dot", "Graph output format")
 
 	graphCmd.Flags().String("file", "", "File to output graph data to. Leave empty for stdout")
+
+	graphCmd.Flags().String("at", "", "Show objects as they were at this time, as RFC 3339 (e.g. 2026-05-01T00:00:00Z) or as a date (e.g. 2026-05-01). Leave empty for now")
 }
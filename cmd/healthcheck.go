@@ -3,11 +3,10 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 
-	"codeberg.org/haulproject/haul/api"
 	"github.com/spf13/cobra"
 )
 
@@ -17,12 +16,17 @@ var healthcheckCmd = &cobra.Command{
 	Aliases: []string{"ping"},
 	Short:   "Print server's healthcheck to test connection",
 	Run: func(cmd *cobra.Command, args []string) {
-		result, err := api.Call(http.MethodGet, "/v1/healthcheck")
+		result, err := newClient().Healthcheck(context.Background())
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		fmt.Println(string(result))
+		message, err := json.Marshal(result)
+		if err != nil {
+			fatal(err)
+		}
+
+		fmt.Println(string(message))
 	},
 }
 
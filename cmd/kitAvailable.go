@@ -0,0 +1,59 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// kitAvailableCmd represents the kitAvailable command
+var kitAvailableCmd = &cobra.Command{
+	Use:   "available",
+	Short: "Prints the kits that are free between --start and --end, optionally filtered",
+	Long: `Prints the kits, optionally filtered, that are neither reserved nor checked out at any time from --start until --end.
+
+--start and --end are times in RFC 3339, or dates from the start or until the end of which the kits are needed.`,
+	Example: `List the demo kits that are free next Tuesday
+
+    $ haul kit available --filter tag=type=demo --start 2026-10-20 --end 2026-10-20`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getFilter(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		start, _ := cmd.Flags().GetString("start")
+		startTime, err := parseStart(start)
+		if err != nil {
+			fatal(err)
+		}
+
+		end, _ := cmd.Flags().GetString("end")
+		endTime, err := parseDue(end)
+		if err != nil {
+			fatal(err)
+		}
+
+		availability, err := newClient().Availability(context.Background(), filter, startTime, endTime)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(availability)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitAvailableCmd)
+
+	addFilterFlags(kitAvailableCmd)
+
+	kitAvailableCmd.Flags().String("start", "", "Time or date from which the kits are needed")
+	kitAvailableCmd.Flags().String("end", "", "Time or date until which the kits are needed")
+
+	kitAvailableCmd.MarkFlagRequired("start")
+	kitAvailableCmd.MarkFlagRequired("end")
+}
@@ -0,0 +1,39 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// kitCheckinCmd represents the kitCheckin command
+var kitCheckinCmd = &cobra.Command{
+	Use:   "checkin OBJECT_ID",
+	Short: "Returns kit identified by OBJECT_ID",
+	Long:  `Returns kit identified by OBJECT_ID, checked out with 'haul kit checkout', and prints its returned loan.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var checkin types.Checkin
+		var err error
+
+		if checkin.Note, err = cmd.Flags().GetString("note"); err != nil {
+			fatal(err)
+		}
+
+		loan, err := newClient().Checkin(context.Background(), objectID(args[0]), checkin)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(loan)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitCheckinCmd)
+
+	kitCheckinCmd.Flags().String("note", "", "Note recorded with the return, e.g. the state of the kit")
+}
@@ -0,0 +1,79 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// kitCheckoutCmd represents the kitCheckout command
+var kitCheckoutCmd = &cobra.Command{
+	Use:   "checkout OBJECT_ID",
+	Short: "Lends kit identified by OBJECT_ID",
+	Long: `Lends kit identified by OBJECT_ID to --to until --due, and prints the recorded loan.
+
+A kit that is already checked out cannot be checked out again before 'haul kit checkin', nor checked out over the reservations of someone else without --force, see 'haul kit reserve'. While it is checked out, the kit cannot be deleted, and its assemblies and components cannot be deleted or moved out of it, without --force.
+
+--due is a time in RFC 3339, or a date by the end of which the kit is due.`,
+	Example: `Lend a kit until the end of November 1st
+
+    $ haul kit checkout 64212ede8e7046c7a1e88557 --to alice --due 2026-11-01 --note "conference demo"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var checkout types.Checkout
+		var err error
+
+		if checkout.Borrower, err = cmd.Flags().GetString("to"); err != nil {
+			fatal(err)
+		}
+
+		if checkout.Note, err = cmd.Flags().GetString("note"); err != nil {
+			fatal(err)
+		}
+
+		due, err := cmd.Flags().GetString("due")
+		if err != nil {
+			fatal(err)
+		}
+
+		if checkout.Due, err = parseDue(due); err != nil {
+			fatal(err)
+		}
+
+		loan, err := newClient().Checkout(context.Background(), objectID(args[0]), checkout, forceOptions(cmd)...)
+
+		var e *client.Error
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict {
+			var conflicts types.ReservationConflicts
+
+			if e.Decode(&conflicts) == nil && len(conflicts.Reservations) > 0 {
+				outputObject(conflicts)
+			}
+		}
+
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(loan)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitCheckoutCmd)
+
+	kitCheckoutCmd.Flags().String("to", "", "Borrower of the kit")
+	kitCheckoutCmd.Flags().String("due", "", "Time by which the kit is due back, e.g. 2026-11-01")
+	kitCheckoutCmd.Flags().String("note", "", "Note recorded with the loan")
+
+	addForceFlag(kitCheckoutCmd)
+
+	kitCheckoutCmd.MarkFlagRequired("to")
+	kitCheckoutCmd.MarkFlagRequired("due")
+}
@@ -0,0 +1,22 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// kitChildrenCmd represents the kitChildren command
+var kitChildrenCmd = &cobra.Command{
+	Use:   "children OBJECT_ID",
+	Short: "Prints the assemblies and components whose target is kit identified by OBJECT_ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printChildren(client.KindKit, args[0])
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitChildrenCmd)
+}
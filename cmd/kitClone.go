@@ -0,0 +1,89 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// kitCloneCmd represents the kitClone command
+var kitCloneCmd = &cobra.Command{
+	Use:   "clone OBJECT_ID",
+	Short: "Copies kit identified by OBJECT_ID with its assemblies and components",
+	Long: `Copies kit identified by OBJECT_ID with its assemblies and components, which get new ObjectIDs, and prints the tree of the copy.
+
+The names and tags of the copies can be rewritten, e.g. to number a batch of identical kits.`,
+	Example: `Build the second demo rig of the quarter
+
+    $ haul kit clone 64212ede8e7046c7a1e88557 --name "Demo rig 2" --replace-name "rig 1=rig 2" --replace-tag batch=1=batch=2`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var clone types.KitClone
+		var err error
+
+		if clone.Name, err = cmd.Flags().GetString("name"); err != nil {
+			fatal(err)
+		}
+
+		if clone.AddTags, err = cmd.Flags().GetStringArray("add-tag"); err != nil {
+			fatal(err)
+		}
+
+		if clone.Names, err = getRewrites(cmd, "replace-name"); err != nil {
+			fatal(err)
+		}
+
+		if clone.Tags, err = getRewrites(cmd, "replace-tag"); err != nil {
+			fatal(err)
+		}
+
+		tree, err := newClient().CloneKit(context.Background(), objectID(args[0]), clone)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(tree)
+	},
+}
+
+// getRewrites returns the rewrites given to the flag name, each as OLD=NEW.
+// Tags containing '=' are split at the separator in the middle, as in
+// "batch=1=batch=2".
+func getRewrites(cmd *cobra.Command, name string) ([]types.Rewrite, error) {
+	values, err := cmd.Flags().GetStringArray(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrites []types.Rewrite
+
+	for _, value := range values {
+		parts := strings.Split(value, "=")
+		if len(parts)%2 != 0 {
+			return nil, fmt.Errorf("Invalid --%s '%s', must be OLD=NEW", name, value)
+		}
+
+		half := len(parts) / 2
+
+		rewrites = append(rewrites, types.Rewrite{
+			Old: strings.Join(parts[:half], "="),
+			New: strings.Join(parts[half:], "="),
+		})
+	}
+
+	return rewrites, nil
+}
+
+func init() {
+	kitCmd.AddCommand(kitCloneCmd)
+
+	kitCloneCmd.Flags().String("name", "", "Name of the copy of the kit, instead of its name")
+	kitCloneCmd.Flags().StringArray("replace-name", nil, "Replace OLD by NEW in the names of the copies, as OLD=NEW. Can be repeated.")
+	kitCloneCmd.Flags().StringArray("replace-tag", nil, "Replace the tag OLD by NEW on the copies, as OLD=NEW, e.g. batch=1=batch=2. Can be repeated.")
+	kitCloneCmd.Flags().StringArray("add-tag", nil, "Tag added to every copy. Can be repeated.")
+}
@@ -0,0 +1,59 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/cli"
+	"github.com/spf13/cobra"
+)
+
+// kitContentsCmd represents the kitContents command
+var kitContentsCmd = &cobra.Command{
+	Use:   "contents OBJECT_ID",
+	Short: "Prints the assemblies and components contained in kit identified by OBJECT_ID",
+	Long: `Prints the assemblies and components contained in kit identified by OBJECT_ID, directly or through an assembly.
+
+With --at, the contents are reconstructed from the history of the objects as they were at that time.`,
+	Example: `What did the kit contain on May 1st 2026
+
+    $ haul kit contents 64212ede8e7046c7a1e88557 --at 2026-05-01T00:00:00Z`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := cli.New()
+
+		at, err := cmd.Flags().GetString("at")
+		if err != nil {
+			fatal(err)
+		}
+
+		t, err := parseAt(at)
+		if err != nil {
+			fatal(err)
+		}
+
+		contents, err := newClient().KitContents(context.Background(), objectID(args[0]), t)
+		if err != nil {
+			fatal(err)
+		}
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		client.OutputStyle = output
+
+		err = client.OutputObject(contents)
+		if err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitContentsCmd)
+
+	kitContentsCmd.Flags().String("at", "", "Time of the contents, as RFC 3339 (e.g. 2026-05-01T00:00:00Z) or as a date (e.g. 2026-05-01). Leave empty for now")
+}
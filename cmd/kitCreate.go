@@ -3,12 +3,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"log"
-	"net/http"
 	"os"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
@@ -41,14 +40,9 @@ var kitCreateCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		kits_bytes, err := json.Marshal(kits.Kits)
+		result, err := newClient().CreateKits(context.Background(), kits.Kits)
 		if err != nil {
-			log.Fatal("json.Marshal:", err)
-		}
-
-		result, err := api.CallWithDataB(http.MethodPost, "/v1/kit", kits_bytes)
-		if err != nil {
-			log.Fatal("api.CallWithDataB:", err)
+			fatal(err)
 		}
 
 		// Using cli object
@@ -56,19 +50,12 @@ var kitCreateCmd = &cobra.Command{
 
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var result_object types.InsertResult
-
-		err = json.Unmarshal(result, &result_object)
-		if err != nil {
-			log.Fatal("Error unmarshalling POST /v1/kit:", err, "\n")
-		}
-
-		err = client.OutputObject(result_object)
+		err = client.OutputObject(result)
 		if err != nil {
 			log.Fatal("Error outputting object:", err)
 		}
@@ -3,11 +3,8 @@
 package cmd
 
 import (
-	"encoding/json"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
@@ -17,36 +14,56 @@ import (
 var kitListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
-	Short:   "Prints values of all kits",
-	Args:    cobra.ExactArgs(0),
+	Short:   "Prints values of kits, optionally filtered and sorted",
+	Example: `List kits tagged "type=demo" and named "Demo rig"
+
+    $ haul kit list --filter tag=type=demo --filter name="Demo rig"
+
+List kits whose name starts with "Demo", or whose status is "broken"
+
+    $ haul kit list --any --filter name_prefix=Demo --filter status=broken
+
+List every kits, following all pages, sorted by status then by name in reverse order
+
+    $ haul kit list --all --sort status,-name`,
+	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		kits_bytes, err := api.Call(http.MethodGet, "/v1/kit")
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var kits types.KitsWithID
+		filter, listOptions, all, err := getListFlags(cmd)
+		if err != nil {
+			fatal(err)
+		}
 
-		if err := json.Unmarshal(kits_bytes, &kits.KitsWithID); err != nil {
-			log.Fatal(err)
+		var kits *types.KitsWithID
+
+		if all {
+			kits, err = newClient().ListAllKits(context.Background(), filter, listOptions)
+		} else {
+			kits, err = newClient().ListKits(context.Background(), filter, listOptions)
+		}
+		if err != nil {
+			fatal(err)
 		}
 
-		err = client.OutputObject(&kits)
+		err = client.OutputObject(kits)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
+
+		printNextPage(kits.Page)
 	},
 }
 
 func init() {
 	kitCmd.AddCommand(kitListCmd)
+
+	addListFlags(kitListCmd)
 }
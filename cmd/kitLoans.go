@@ -0,0 +1,31 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// kitLoansCmd represents the kitLoans command
+var kitLoansCmd = &cobra.Command{
+	Use:   "loans OBJECT_ID",
+	Short: "Prints the loans of kit identified by OBJECT_ID",
+	Long: `Prints the loans of kit identified by OBJECT_ID, from the oldest check-out.
+
+The loans of deleted kits are kept.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loans, err := newClient().KitLoans(context.Background(), objectID(args[0]))
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(loans)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitLoansCmd)
+}
@@ -0,0 +1,41 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// kitPatchCmd represents the kitPatch command
+var kitPatchCmd = &cobra.Command{
+	Use:   "patch OBJECT_ID",
+	Short: "Partially update a kit with a patch read from a file or stdin",
+	Long: `Partially update a kit, identified by an ObjectID, with a merge patch (RFC 7386) or a JSON patch (RFC 6902) read from a file or stdin.
+
+In a merge patch, a null value removes the field. A JSON patch can add to and remove from the tags, and test values before changing them.
+
+The patch is applied on the server, so that it is not lost when the kit is changed at the same time.`,
+	Example: `Set the status of kit 64212ede8e7046c7a1e88557 to "broken", and remove its tags
+
+    $ echo '{ "status": "broken", "tags": null }' | haul kit patch 64212ede8e7046c7a1e88557
+
+Add a tag, only if the status is still "ok"
+
+    $ haul kit patch 64212ede8e7046c7a1e88557 --file - <<EOF
+    [
+      { "op": "test", "path": "/status", "value": "ok" },
+      { "op": "add", "path": "/tags/-", "value": "checked=2026" }
+    ]
+    EOF`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		patchObject(cmd, client.KindKit, args[0])
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitPatchCmd)
+
+	addPatchFlags(kitPatchCmd)
+}
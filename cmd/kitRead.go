@@ -3,14 +3,9 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
+	"context"
 
-	"codeberg.org/haulproject/haul/api"
 	"codeberg.org/haulproject/haul/cli"
-	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -23,28 +18,21 @@ var kitReadCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := cli.New()
 
-		kit_bytes, err := api.Call(http.MethodGet, fmt.Sprintf("/v1/kit/%s", args[0]))
+		kit, err := newClient().ReadKit(context.Background(), objectID(args[0]))
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		output, err := rootCmd.PersistentFlags().GetString("output")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		client.OutputStyle = output
 
-		var kit types.KitWithID
-
-		err = json.Unmarshal(kit_bytes, &kit)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		err = client.OutputObject(&kit)
+		err = client.OutputObject(kit)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 	},
 }
@@ -0,0 +1,39 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// kitReservationsCmd represents the kitReservations command
+var kitReservationsCmd = &cobra.Command{
+	Use:   "reservations OBJECT_ID",
+	Short: "Prints the reservations of kit identified by OBJECT_ID",
+	Long:  `Prints the reservations of kit identified by OBJECT_ID, from the earliest start.`,
+	Example: `Print the reservations of a kit for November
+
+    $ haul kit reservations 64212ede8e7046c7a1e88557 --from 2026-11-01 --to 2026-11-30`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getReservationFilter(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		reservations, err := newClient().KitReservations(context.Background(), objectID(args[0]), filter)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(reservations)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitReservationsCmd)
+
+	addReservationFilterFlags(kitReservationsCmd)
+}
@@ -0,0 +1,80 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// kitReserveCmd represents the kitReserve command
+var kitReserveCmd = &cobra.Command{
+	Use:   "reserve OBJECT_ID",
+	Short: "Books kit identified by OBJECT_ID in advance",
+	Long: `Books kit identified by OBJECT_ID for --for, from --start until --end, and prints the recorded reservation.
+
+A kit cannot be reserved at a time it is already reserved or checked out, the conflicting reservations and loans are printed instead. While reserved, the kit cannot be checked out to someone else without --force.
+
+--start and --end are times in RFC 3339, or dates from the start or until the end of which the kit is booked.`,
+	Example: `Book a demo kit for a two days event
+
+    $ haul kit reserve 64212ede8e7046c7a1e88557 --for alice --start 2026-11-03 --end 2026-11-04 --note "trade show"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var reserve types.Reserve
+		var err error
+
+		if reserve.Holder, err = cmd.Flags().GetString("for"); err != nil {
+			fatal(err)
+		}
+
+		if reserve.Note, err = cmd.Flags().GetString("note"); err != nil {
+			fatal(err)
+		}
+
+		start, _ := cmd.Flags().GetString("start")
+		if reserve.Start, err = parseStart(start); err != nil {
+			fatal(err)
+		}
+
+		end, _ := cmd.Flags().GetString("end")
+		if reserve.End, err = parseDue(end); err != nil {
+			fatal(err)
+		}
+
+		reservation, err := newClient().Reserve(context.Background(), objectID(args[0]), reserve)
+
+		var e *client.Error
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict {
+			var conflicts types.ReservationConflicts
+
+			if e.Decode(&conflicts) == nil {
+				outputObject(conflicts)
+			}
+		}
+
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(reservation)
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitReserveCmd)
+
+	kitReserveCmd.Flags().String("for", "", "Holder of the reservation")
+	kitReserveCmd.Flags().String("start", "", "Time or date from which the kit is booked")
+	kitReserveCmd.Flags().String("end", "", "Time or date until which the kit is booked")
+	kitReserveCmd.Flags().String("note", "", "Note recorded with the reservation")
+
+	kitReserveCmd.MarkFlagRequired("for")
+	kitReserveCmd.MarkFlagRequired("start")
+	kitReserveCmd.MarkFlagRequired("end")
+}
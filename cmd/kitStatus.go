@@ -0,0 +1,30 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// kitStatusCmd represents the kitStatus command
+var kitStatusCmd = &cobra.Command{
+	Use:   "status OBJECT_ID STATUS",
+	Short: "Changes the status of kit identified by OBJECT_ID to STATUS",
+	Long: `Changes the status of kit identified by OBJECT_ID to STATUS.
+
+The status and the transition from the current status are validated against the statuses allowed by the server, see 'haul statuses'.`,
+	Example: `Send a broken kit back to the manufacturer
+
+    $ haul kit status 64212ede8e7046c7a1e88557 rma`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setStatus(cmd, client.KindKit, args[0], args[1])
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitStatusCmd)
+
+	addIfMatchFlag(kitStatusCmd)
+}
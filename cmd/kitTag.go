@@ -3,13 +3,13 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -30,69 +30,58 @@ var kitTagCmd = &cobra.Command{
 		add, _ = cmd.Flags().GetStringSlice("add")
 		remove, _ = cmd.Flags().GetStringSlice("remove")
 
+		api := newClient()
+		ctx := context.Background()
+		id := objectID(args[0])
+
 		if clear {
 			// Clear tags
 			log.Println("Clearing tags")
 
-			result, err := api.Call(http.MethodDelete, fmt.Sprintf("/v1/kit/%s/tags", args[0]))
+			result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+				return api.ClearTags(ctx, client.KindKit, id, opts...)
+			})
 			if err != nil {
-				log.Fatalf("api.CallWithData: %s\n", err)
+				fatal(err)
 			}
 
-			log.Println(string(result))
+			outputObject(result)
 
 			os.Exit(0)
 		}
 
 		if len(remove) > 0 {
 			// Remove some tags
-			data, err := json.Marshal(remove)
-			if err != nil {
-				log.Fatalf("json.Marshal: %s", err)
-			}
-
-			result, err := api.CallWithData(http.MethodPost, fmt.Sprintf("/v1/kit/%s/tags/remove", args[0]), data)
+			result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+				return api.RemoveTags(ctx, client.KindKit, id, remove, opts...)
+			})
 			if err != nil {
-				log.Fatalf("api.Call: %s\n", err)
+				fatal(err)
 			}
 
-			log.Println(result)
+			outputObject(result)
 			os.Exit(0)
 		}
 
 		if len(add) > 0 {
 			// Add some tags
-			data, err := json.Marshal(add)
-			if err != nil {
-				log.Fatalf("json.Marshal: %s", err)
-			}
-
-			result, err := api.CallWithData(http.MethodPost, fmt.Sprintf("/v1/kit/%s/tags/add", args[0]), data)
+			result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+				return api.AddTags(ctx, client.KindKit, id, add, opts...)
+			})
 			if err != nil {
-				log.Fatalf("api.Call: %s\n", err)
+				fatal(err)
 			}
 
-			log.Println(result)
+			outputObject(result)
 			os.Exit(0)
 		}
 
 		// Show tags
-		result, err := api.Call(http.MethodGet, fmt.Sprintf("/v1/kit/%s/tags", args[0]))
+		tags, err := api.Tags(ctx, client.KindKit, id)
 		if err != nil {
-			log.Fatalf("api.Call: %s\n", err)
+			fatal(err)
 		}
 
-		var tags []string
-		err = json.Unmarshal(result, &tags)
-		if err != nil {
-			log.Fatalf("json.Unmarshal: %s\n", err)
-		}
-
-		// JSON print
-		//fmt.Println(tags)
-
-		//fmt.Println(result)
-
 		// Pretty print
 		for _, tag := range tags {
 			fmt.Println(tag)
@@ -104,6 +93,8 @@ var kitTagCmd = &cobra.Command{
 func init() {
 	kitCmd.AddCommand(kitTagCmd)
 
+	addIfMatchFlag(kitTagCmd)
+
 	kitTagCmd.Flags().Bool("clear", false, "If set, will delete all tags in this object")
 	kitTagCmd.Flags().StringSlice("add", nil, "List of tags to add")
 	kitTagCmd.Flags().StringSlice("remove", nil, "List of tags to remove")
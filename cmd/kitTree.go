@@ -0,0 +1,30 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// kitTreeCmd represents the kitTree command
+var kitTreeCmd = &cobra.Command{
+	Use:   "tree OBJECT_ID",
+	Short: "Prints the assemblies and components of kit identified by OBJECT_ID as a tree",
+	Long: `Prints the assemblies and components of kit identified by OBJECT_ID as a tree, each object under its target.
+
+Use '--output json' for the nested structure.`,
+	Example: `What does the kit contain, without the components of its assemblies
+
+    $ haul kit tree 64212ede8e7046c7a1e88557 --depth 1`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printTree(cmd, client.KindKit, args[0])
+	},
+}
+
+func init() {
+	kitCmd.AddCommand(kitTreeCmd)
+
+	addTreeFlags(kitTreeCmd)
+}
@@ -3,12 +3,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
 
-	"codeberg.org/haulproject/haul/api"
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/cobra"
 )
 
@@ -30,35 +29,34 @@ To empty a field, provide the zero value for the field. Note that "name" cannot
 
 		var kit map[string]interface{}
 
-		id := args[0]
+		id := objectID(args[0])
 
 		update, err := cmd.Flags().GetString("data")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		err = json.Unmarshal([]byte(update), &kit)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		currentKit, err := json.Marshal(kit)
+		result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+			return newClient().Update(context.Background(), client.KindKit, id, kit, opts...)
+		})
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
-		result, err := api.CallWithData(http.MethodPut, fmt.Sprintf("/v1/kit/%s", id), currentKit)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fmt.Printf("%s", result)
+		outputObject(result)
 	},
 }
 
 func init() {
 	kitCmd.AddCommand(kitUpdateCmd)
 
+	addIfMatchFlag(kitUpdateCmd)
+
 	kitUpdateCmd.Flags().String("data", "", "Data to use in the update, in JSON format")
 	kitUpdateCmd.MarkFlagRequired("data")
 }
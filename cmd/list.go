@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// addListFlags adds the filter and pagination flags read by getListFlags to a
+// list command.
+func addListFlags(cmd *cobra.Command) {
+	addFilterFlags(cmd)
+
+	cmd.Flags().Int64("limit", 0, "Maximum number of objects per page, 0 for the server default")
+	cmd.Flags().String("after", "", "Cursor of the page to list, as printed after the previous page")
+	cmd.Flags().Bool("all", false, "List every page, instead of only the first one")
+	cmd.Flags().String("sort", "", `Comma-separated fields to sort by, each prefixed by '-' for descending order, e.g. "name,-status"`)
+}
+
+// getListFlags returns the filter and list options described by the flags
+// added by addListFlags, and whether every page should be listed.
+func getListFlags(cmd *cobra.Command) (types.Filter, types.ListOptions, bool, error) {
+	var listOptions types.ListOptions
+
+	filter, err := getFilter(cmd)
+	if err != nil {
+		return filter, listOptions, false, err
+	}
+
+	if listOptions.Limit, err = cmd.Flags().GetInt64("limit"); err != nil {
+		return filter, listOptions, false, err
+	}
+
+	if listOptions.After, err = cmd.Flags().GetString("after"); err != nil {
+		return filter, listOptions, false, err
+	}
+
+	if listOptions.Sort, err = cmd.Flags().GetString("sort"); err != nil {
+		return filter, listOptions, false, err
+	}
+
+	all, err := cmd.Flags().GetBool("all")
+
+	return filter, listOptions, all, err
+}
+
+// printNextPage tells how to list the pages following page, if any.
+func printNextPage(page types.Page) {
+	if page.Next != "" {
+		fmt.Fprintf(os.Stderr, "%d objects in total, list the next page with '--after %s', or every page with '--all'\n", page.Total, page.Next)
+	}
+}
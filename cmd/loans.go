@@ -0,0 +1,54 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// loansCmd represents the loans command
+var loansCmd = &cobra.Command{
+	Use:   "loans",
+	Short: "Prints the loans of kits, see 'haul kit checkout'",
+	Long: `Prints the loans of kits, from the earliest due date.
+
+Every loan is printed by default, including the returned ones.`,
+	Example: `Print the kits that should have been returned
+
+    $ haul loans --overdue`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		var filter types.LoanFilter
+		var err error
+
+		if filter.Active, err = cmd.Flags().GetBool("active"); err != nil {
+			fatal(err)
+		}
+
+		if filter.Overdue, err = cmd.Flags().GetBool("overdue"); err != nil {
+			fatal(err)
+		}
+
+		if filter.Borrower, err = cmd.Flags().GetString("borrower"); err != nil {
+			fatal(err)
+		}
+
+		loans, err := newClient().Loans(context.Background(), filter)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(loans)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loansCmd)
+
+	loansCmd.Flags().Bool("active", false, "Only print the kits still checked out")
+	loansCmd.Flags().Bool("overdue", false, "Only print the kits still checked out after their due date")
+	loansCmd.Flags().String("borrower", "", "Only print the loans to this borrower")
+}
@@ -0,0 +1,30 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// locationCmd represents the location command
+var locationCmd = &cobra.Command{
+	Use:     "location",
+	Aliases: []string{"l"},
+	Short:   "Locations are the sites, rooms, racks and shelves where kits, assemblies and components are stored",
+	Long: `Locations are the sites, rooms, racks and shelves where kits, assemblies and components are stored.
+
+Locations are nested from the largest to the smallest kind: site > room > rack > shelf. A location's "parent" is the larger location it is in, e.g. the room of a rack.
+
+Kits, and assemblies and components without a target, can be put at a location by setting their "location". Objects with a target are wherever their target is: setting a target clears their location.`,
+	Example: `Put kit 64212ede8e7046c7a1e88557 on shelf 6421301c8e7046c7a1e8855d
+
+    $ haul kit update 64212ede8e7046c7a1e88557 --data '{ "location": "6421301c8e7046c7a1e8855d" }'
+
+List the kits on that shelf
+
+    $ haul kit list --filter location=6421301c8e7046c7a1e8855d`,
+}
+
+func init() {
+	rootCmd.AddCommand(locationCmd)
+}
@@ -0,0 +1,31 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// locationContentsCmd represents the locationContents command
+var locationContentsCmd = &cobra.Command{
+	Use:   "contents OBJECT_ID",
+	Short: "Prints the locations, kits, assemblies and components in location identified by OBJECT_ID",
+	Long: `Prints the locations in location identified by OBJECT_ID, recursively, then the kits, assemblies and components at any of them.
+
+The assemblies and components of the kits are listed with 'haul kit tree'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		contents, err := newClient().LocationContents(context.Background(), objectID(args[0]))
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(contents)
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationContentsCmd)
+}
@@ -0,0 +1,76 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"codeberg.org/haulproject/haul/cli"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// locationCreateCmd represents the locationCreate command
+var locationCreateCmd = &cobra.Command{
+	Use:     "create LOCATION...",
+	Aliases: []string{"add"},
+	Short:   "Create locations in the database",
+	Long: `Create locations in the database, each given in JSON format.
+
+The "kind" of a location is one of { site | room | rack | shelf }, and its "parent", if any, must be a larger kind of location.
+
+The "fields" field holds the custom fields of the location, as defined by 'haul schema'.`,
+	Example: `Create a site, then a room inside it
+
+    $ haul location create '{ "name": "HQ", "kind": "site" }'
+    $ haul location create '{ "name": "Lab", "kind": "room", "parent": "6421301c8e7046c7a1e8855d" }'`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var locations types.Locations
+
+		for _, arg := range args {
+			var location types.Location
+
+			err := json.Unmarshal([]byte(arg), &location)
+			if err != nil {
+				log.Fatalf(`Bad argument: %s
+
+%s`, arg, err)
+			}
+
+			locations.Locations = append(locations.Locations, location)
+
+		}
+
+		if len(locations.Locations) == 0 {
+			os.Exit(1)
+		}
+
+		result, err := newClient().CreateLocations(context.Background(), locations.Locations)
+		if err != nil {
+			fatal(err)
+		}
+
+		// Using cli object
+		client := cli.New()
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		client.OutputStyle = output
+
+		err = client.OutputObject(result)
+		if err != nil {
+			log.Fatal("Error outputting object:", err)
+		}
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationCreateCmd)
+}
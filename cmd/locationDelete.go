@@ -0,0 +1,42 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// locationDeleteCmd represents the locationDelete command
+var locationDeleteCmd = &cobra.Command{
+	Use:     "delete OBJECT_ID...",
+	Aliases: []string{"rm", "remove", "del"},
+	Short:   "Deletes locations identified by one or more OBJECT_ID",
+	Long: `Deletes locations identified by one or more OBJECT_ID.
+
+By default, a location that still contains locations, kits, assemblies or components is not deleted, and what it contains is listed instead.
+
+Use --orphan to take what it contains out of it before deleting the location. What a location contains is never deleted with it.
+
+Deleted locations go to the trash, from which they can be restored with 'haul trash restore'.`,
+	Example: `Delete a shelf, leaving the kits it held without a location
+
+    $ haul location delete --orphan 6421301c8e7046c7a1e8855d`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		onReferenced := types.OnReferencedReject
+
+		if orphan, _ := cmd.Flags().GetBool("orphan"); orphan {
+			onReferenced = types.OnReferencedOrphan
+		}
+
+		deleteObjects(client.KindLocation, args, onReferenced)
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationDeleteCmd)
+
+	locationDeleteCmd.Flags().Bool("orphan", false, "Take the locations and objects in this location out of it")
+}
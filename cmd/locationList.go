@@ -0,0 +1,69 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/cli"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// locationListCmd represents the locationList command
+var locationListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Prints values of locations, optionally filtered and sorted",
+	Example: `List locations tagged "floor=5"
+
+    $ haul location list --filter tag=floor=5
+
+List locations whose name starts with "Rack"
+
+    $ haul location list --filter name_prefix=Rack
+
+List every location, following all pages, sorted by kind then by name
+
+    $ haul location list --all --sort kind,name`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := cli.New()
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		client.OutputStyle = output
+
+		filter, listOptions, all, err := getListFlags(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		var locations *types.LocationsWithID
+
+		if all {
+			locations, err = newClient().ListAllLocations(context.Background(), filter, listOptions)
+		} else {
+			locations, err = newClient().ListLocations(context.Background(), filter, listOptions)
+		}
+		if err != nil {
+			fatal(err)
+		}
+
+		err = client.OutputObject(locations)
+		if err != nil {
+			fatal(err)
+		}
+
+		printNextPage(locations.Page)
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationListCmd)
+
+	addListFlags(locationListCmd)
+}
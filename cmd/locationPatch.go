@@ -0,0 +1,41 @@
+/*
+ */
+package cmd
+
+import (
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// locationPatchCmd represents the locationPatch command
+var locationPatchCmd = &cobra.Command{
+	Use:   "patch OBJECT_ID",
+	Short: "Partially update a location with a patch read from a file or stdin",
+	Long: `Partially update a location, identified by an ObjectID, with a merge patch (RFC 7386) or a JSON patch (RFC 6902) read from a file or stdin.
+
+In a merge patch, a null value removes the field. A JSON patch can add to and remove from the tags, and test values before changing them.
+
+The patch is applied on the server, so that it is not lost when the location is changed at the same time.`,
+	Example: `Take location 6421301c8e7046c7a1e8855d out of its parent, and remove its tags
+
+    $ echo '{ "parent": null, "tags": null }' | haul location patch 6421301c8e7046c7a1e8855d
+
+Add a tag, only if it is still a rack
+
+    $ haul location patch 6421301c8e7046c7a1e8855d --file - <<EOF
+    [
+      { "op": "test", "path": "/kind", "value": "rack" },
+      { "op": "add", "path": "/tags/-", "value": "checked=2026" }
+    ]
+    EOF`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		patchObject(cmd, client.KindLocation, args[0])
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationPatchCmd)
+
+	addPatchFlags(locationPatchCmd)
+}
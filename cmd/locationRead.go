@@ -0,0 +1,42 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/cli"
+	"github.com/spf13/cobra"
+)
+
+// locationReadCmd represents the locationRead command
+var locationReadCmd = &cobra.Command{
+	Use:     "read OBJECT_ID",
+	Aliases: []string{"get"},
+	Short:   "Prints values of location identified by OBJECT_ID",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := cli.New()
+
+		location, err := newClient().ReadLocation(context.Background(), objectID(args[0]))
+		if err != nil {
+			fatal(err)
+		}
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		client.OutputStyle = output
+
+		err = client.OutputObject(location)
+		if err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationReadCmd)
+}
@@ -0,0 +1,62 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// locationUpdateCmd represents the locationUpdate command
+var locationUpdateCmd = &cobra.Command{
+	Use:     "update",
+	Aliases: []string{"u", "set", "s"},
+	Short:   "Update a location in the database",
+	Long: `Update a location in the database, identified by an ObjectID, with updated fields in JSON format.
+
+Any fields not specified will be unaffected by the update.
+
+To empty a field, provide the zero value for the field. Note that "name" cannot be made empty.`,
+	Example: `Rename location identified by ObjectID 6421301c8e7046c7a1e8855d to "Rack 01", and move it to room 6421301c8e7046c7a1e8855a
+
+    $ haul location update 6421301c8e7046c7a1e8855d --data '{ "name": "Rack 01", "parent": "6421301c8e7046c7a1e8855a" }'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var location map[string]interface{}
+
+		id := objectID(args[0])
+
+		update, err := cmd.Flags().GetString("data")
+		if err != nil {
+			fatal(err)
+		}
+
+		err = json.Unmarshal([]byte(update), &location)
+		if err != nil {
+			fatal(err)
+		}
+
+		result, err := changeIfMatch(cmd, func(opts ...client.Option) (*types.UpdateResult, error) {
+			return newClient().Update(context.Background(), client.KindLocation, id, location, opts...)
+		})
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationUpdateCmd)
+
+	addIfMatchFlag(locationUpdateCmd)
+
+	locationUpdateCmd.Flags().String("data", "", "Data to use in the update, in JSON format")
+	locationUpdateCmd.MarkFlagRequired("data")
+}
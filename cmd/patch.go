@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// Values of the --type flag of the patch commands.
+const (
+	patchTypeMerge = "merge"
+	patchTypeJSON  = "json"
+)
+
+// addPatchFlags adds the flags read by patchObject to a patch command.
+func addPatchFlags(cmd *cobra.Command) {
+	addIfMatchFlag(cmd)
+	addForceFlag(cmd)
+
+	cmd.Flags().StringP("file", "f", "-", "File holding the patch, '-' for stdin")
+	cmd.Flags().String("type", "", `Type of the patch { merge | json }. By default, an object is a merge patch (RFC 7386)
+and a list is a JSON patch (RFC 6902)`)
+}
+
+// patchObject applies the patch given to cmd to the object arg of kind, and
+// prints the result.
+func patchObject(cmd *cobra.Command, kind client.Kind, arg string) {
+	id := objectID(arg)
+
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		fatal(err)
+	}
+
+	patchType, err := cmd.Flags().GetString("type")
+	if err != nil {
+		fatal(err)
+	}
+
+	var data []byte
+
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	if patchType == "" {
+		patchType = patchTypeMerge
+		if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+			patchType = patchTypeJSON
+		}
+	}
+
+	api := newClient()
+
+	var change func(opts ...client.Option) (*types.UpdateResult, error)
+
+	switch patchType {
+	case patchTypeMerge:
+		var patch map[string]interface{}
+
+		if err := json.Unmarshal(data, &patch); err != nil {
+			fatal(fmt.Errorf("Invalid merge patch: %w", err))
+		}
+
+		change = func(opts ...client.Option) (*types.UpdateResult, error) {
+			return api.MergePatch(context.Background(), kind, id, patch, opts...)
+		}
+	case patchTypeJSON:
+		var operations []types.PatchOperation
+
+		if err := json.Unmarshal(data, &operations); err != nil {
+			fatal(fmt.Errorf("Invalid JSON patch: %w", err))
+		}
+
+		change = func(opts ...client.Option) (*types.UpdateResult, error) {
+			return api.JSONPatch(context.Background(), kind, id, operations, opts...)
+		}
+	default:
+		fatal(fmt.Errorf("Invalid patch type '%s', must be one of { %s | %s }", patchType, patchTypeMerge, patchTypeJSON))
+	}
+
+	result, err := changeIfMatch(cmd, change)
+	if err != nil {
+		fatal(err)
+	}
+
+	outputObject(result)
+}
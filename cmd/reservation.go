@@ -0,0 +1,17 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// reservationCmd represents the reservation command
+var reservationCmd = &cobra.Command{
+	Use:   "reservation",
+	Short: "Bookings of kits in advance, see 'haul kit reserve'",
+}
+
+func init() {
+	rootCmd.AddCommand(reservationCmd)
+}
@@ -0,0 +1,50 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// reservationCalendarCmd represents the reservationCalendar command
+var reservationCalendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "Prints the reservations as an iCalendar feed, optionally filtered",
+	Long: `Prints the reservations as an iCalendar feed, optionally filtered, e.g. to import them in a calendar client.
+
+Calendar clients can also subscribe to the feed at /v1/reservations/calendar, which accepts the same filters as query parameters. When the server requires an API key, it can be given as the "key" query parameter of the feed.`,
+	Example: `Save the reservations of a kit
+
+    $ haul reservation calendar --kit 64212ede8e7046c7a1e88557 > reservations.ics`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getReservationFilter(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		if kit, _ := cmd.Flags().GetString("kit"); kit != "" {
+			filter.Kit = objectID(kit).Hex()
+		}
+
+		feed, err := newClient().ReservationsCalendar(context.Background(), filter)
+		if err != nil {
+			fatal(err)
+		}
+
+		if _, err := os.Stdout.Write(feed); err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	reservationCmd.AddCommand(reservationCalendarCmd)
+
+	addReservationFilterFlags(reservationCalendarCmd)
+
+	reservationCalendarCmd.Flags().String("kit", "", "Only include the reservations of this kit")
+}
@@ -0,0 +1,33 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// reservationCancelCmd represents the reservationCancel command
+var reservationCancelCmd = &cobra.Command{
+	Use:     "cancel RESERVATION_ID...",
+	Aliases: []string{"rm", "delete"},
+	Short:   "Cancels reservations identified by one or more RESERVATION_ID",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		api := newClient()
+
+		for _, arg := range args {
+			result, err := api.CancelReservation(context.Background(), objectID(arg))
+			if err != nil {
+				fatal(err)
+			}
+
+			outputObject(result)
+		}
+	},
+}
+
+func init() {
+	reservationCmd.AddCommand(reservationCancelCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// addReservationFilterFlags adds the flags read by getReservationFilter to a
+// command listing reservations.
+func addReservationFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("holder", "", "Only list the reservations of this holder")
+	cmd.Flags().String("from", "", "Only list the reservations ending after this time or date")
+	cmd.Flags().String("to", "", "Only list the reservations starting before this time or date")
+}
+
+// getReservationFilter returns the types.ReservationFilter described by the
+// flags added by addReservationFilterFlags.
+func getReservationFilter(cmd *cobra.Command) (types.ReservationFilter, error) {
+	var filter types.ReservationFilter
+	var err error
+
+	if filter.Holder, err = cmd.Flags().GetString("holder"); err != nil {
+		return filter, err
+	}
+
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		t, err := parseStart(from)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.From = t.Format(time.RFC3339)
+	}
+
+	if to, _ := cmd.Flags().GetString("to"); to != "" {
+		t, err := parseDue(to)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.To = t.Format(time.RFC3339)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,46 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// reservationListCmd represents the reservationList command
+var reservationListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Prints the reservations of every kit, optionally filtered",
+	Long:    `Prints the reservations of every kit, optionally filtered, from the earliest start.`,
+	Example: `Print the upcoming reservations of alice
+
+    $ haul reservation list --holder alice --from 2026-10-18`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getReservationFilter(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		if kit, _ := cmd.Flags().GetString("kit"); kit != "" {
+			filter.Kit = objectID(kit).Hex()
+		}
+
+		reservations, err := newClient().Reservations(context.Background(), filter)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(reservations)
+	},
+}
+
+func init() {
+	reservationCmd.AddCommand(reservationListCmd)
+
+	addReservationFilterFlags(reservationListCmd)
+
+	reservationListCmd.Flags().String("kit", "", "Only list the reservations of this kit")
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,6 +18,17 @@ var cfgFile string
 var rootCmd = &cobra.Command{
 	Use:   "haul",
 	Short: "Inventory management system for patchwork components and assets.",
+	Long: `Inventory management system for patchwork components and assets.
+
+Commands calling the api exit with a status telling why they failed:
+
+  1  any other error, e.g. invalid arguments
+  2  the server could not be reached
+  3  the server or its database failed
+  4  the request was refused as invalid
+  5  the api key is missing or invalid
+  6  the object does not exist
+  7  the change conflicts with the state of the objects, or with a concurrent change`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -56,6 +68,10 @@ func init() {
 	rootCmd.PersistentFlags().String("api-key", "", "Remote api key (config: 'api.key')")
 	viper.BindPFlag("api.key", rootCmd.PersistentFlags().Lookup("api-key"))
 
+	// api.timeout
+	rootCmd.PersistentFlags().Duration("api-timeout", time.Minute, "Remote api request timeout, 0 for none (config: 'api.timeout')")
+	viper.BindPFlag("api.timeout", rootCmd.PersistentFlags().Lookup("api-timeout"))
+
 	rootCmd.PersistentFlags().StringP("output", "o", "tabby", "Output style { tabby | json | json_pretty }")
 	viper.BindPFlag("cli.output", rootCmd.PersistentFlags().Lookup("output"))
 }
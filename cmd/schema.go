@@ -0,0 +1,20 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Custom fields of the objects of each kind",
+	Long: `Custom fields of the objects of each kind { component | assembly | kit | location }.
+
+Custom fields are set under the "fields" of objects, e.g. { "name": "Laptop", "fields": { "serial": "SN-1234" } }, and are checked by the server against the schema of their kind on create and update. Objects can be listed by custom field with '--filter field.<name>=VALUE', see 'haul component list --help'.`,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,30 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// schemaGetCmd represents the schemaGet command
+var schemaGetCmd = &cobra.Command{
+	Use:     "get KIND",
+	Aliases: []string{"read"},
+	Short:   "Print the custom fields of the objects of KIND",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := newClient().Schema(context.Background(), client.Kind(args[0]))
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(schema)
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaGetCmd)
+}
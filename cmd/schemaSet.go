@@ -0,0 +1,149 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// schemaSetCmd represents the schemaSet command
+var schemaSetCmd = &cobra.Command{
+	Use:   "set KIND",
+	Short: "Replace the custom fields of the objects of KIND",
+	Long: `Replace the custom fields of the objects of KIND, given by repeated --field flags or as a JSON schema with --file, and print the new schema.
+
+A --field is NAME:TYPE, followed by ':unique' if no two objects of KIND may share a value, and by ':required' if every object of KIND must have one. TYPE is one of { string | integer | decimal | boolean | date | enum=VALUE|VALUE... }.
+
+The schema is refused, and the objects not matching it are printed, if existing objects of KIND have unknown fields, values of the wrong type, missing required fields or duplicate unique values. Without any --field nor --file, the custom fields of KIND are removed.`,
+	Example: `Give components a unique serial number, a purchase date and a price
+
+    $ haul schema set component --field serial:string:unique --field purchase_date:date --field price:decimal
+
+Give kits a required condition
+
+    $ haul schema set kit --field 'condition:enum=new|used|broken:required'
+
+Set the schema of locations from a file
+
+    $ haul schema set location -f schema.json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := getSchema(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		set, err := newClient().SetSchema(context.Background(), client.Kind(args[0]), schema)
+
+		var e *client.Error
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict {
+			var conflicts types.SchemaConflicts
+
+			if e.Decode(&conflicts) == nil && len(conflicts.Objects) > 0 {
+				outputObject(conflicts)
+			}
+		}
+
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(set)
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaSetCmd)
+
+	schemaSetCmd.Flags().StringArray("field", nil, "Custom field NAME:TYPE[:unique][:required]. Can be repeated")
+	schemaSetCmd.Flags().StringP("file", "f", "", "File holding the schema as JSON, '-' for stdin")
+}
+
+// getSchema returns the types.Schema described by the flags of cmd.
+func getSchema(cmd *cobra.Command) (types.Schema, error) {
+	schema := types.Schema{Fields: []types.Field{}}
+
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return schema, err
+	}
+
+	fields, err := cmd.Flags().GetStringArray("field")
+	if err != nil {
+		return schema, err
+	}
+
+	if file != "" {
+		if len(fields) > 0 {
+			return schema, fmt.Errorf("--field and --file cannot be used together")
+		}
+
+		var data []byte
+
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return schema, err
+		}
+
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return schema, fmt.Errorf("Invalid schema: %w", err)
+		}
+
+		return schema, nil
+	}
+
+	for _, f := range fields {
+		field, err := parseField(f)
+		if err != nil {
+			return schema, err
+		}
+
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	return schema, nil
+}
+
+// parseField returns the types.Field given to a --field flag, e.g.
+// "serial:string:unique" or "condition:enum=new|used".
+func parseField(f string) (types.Field, error) {
+	parts := strings.Split(f, ":")
+	if len(parts) < 2 {
+		return types.Field{}, fmt.Errorf("Invalid field '%s', must be NAME:TYPE[:unique][:required]", f)
+	}
+
+	field := types.Field{Name: parts[0], Type: parts[1]}
+
+	if strings.HasPrefix(field.Type, types.FieldEnum+"=") {
+		field.Values = strings.Split(strings.TrimPrefix(field.Type, types.FieldEnum+"="), "|")
+		field.Type = types.FieldEnum
+	}
+
+	for _, option := range parts[2:] {
+		switch option {
+		case "unique":
+			field.Unique = true
+		case "required":
+			field.Required = true
+		default:
+			return types.Field{}, fmt.Errorf("Invalid option '%s' of field '%s', must be one of { unique | required }", option, f)
+		}
+	}
+
+	return field, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// readStatus returns the status and the version of the object id of kind.
+func readStatus(ctx context.Context, api *client.Client, kind client.Kind, id primitive.ObjectID) (string, int64, error) {
+	switch kind {
+	case client.KindComponent:
+		component, err := api.ReadComponent(ctx, id)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return component.Status, component.Version, nil
+	case client.KindAssembly:
+		assembly, err := api.ReadAssembly(ctx, id)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return assembly.Status, assembly.Version, nil
+	default:
+		kit, err := api.ReadKit(ctx, id)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return kit.Status, kit.Version, nil
+	}
+}
+
+// setStatus changes the status of the object of kind identified by arg to
+// status, once the transition is validated against the statuses allowed by
+// the server, and prints the result.
+//
+// The change only applies to the object as it was validated, and is
+// validated again if it was modified since, see addIfMatchFlag.
+func setStatus(cmd *cobra.Command, kind client.Kind, arg, status string) {
+	api := newClient()
+	ctx := context.Background()
+	id := objectID(arg)
+
+	statuses, err := api.Statuses(ctx)
+	if err != nil {
+		fatal(err)
+	}
+
+	if err := statuses.Validate(status); err != nil {
+		log.Println(err)
+		os.Exit(exitInvalid)
+	}
+
+	retries := conflictRetries
+	if cmd.Flags().Changed("if-match") {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		from, version, err := readStatus(ctx, api, kind, id)
+		if err != nil {
+			fatal(err)
+		}
+
+		if err := statuses.ValidateTransition(from, status); err != nil {
+			log.Println(err)
+			os.Exit(exitInvalid)
+		}
+
+		if cmd.Flags().Changed("if-match") {
+			if version, err = cmd.Flags().GetInt64("if-match"); err != nil {
+				fatal(err)
+			}
+		}
+
+		result, err := api.Update(ctx, kind, id, map[string]interface{}{"status": status}, client.IfMatch(version))
+		if client.IsPreconditionFailed(err) && attempt < retries {
+			continue
+		}
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+		return
+	}
+}
@@ -0,0 +1,28 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// statusesCmd represents the statuses command
+var statusesCmd = &cobra.Command{
+	Use:   "statuses",
+	Short: "Print the statuses allowed by the server, with the statuses each may change to",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses, err := newClient().Statuses(context.Background())
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(statuses)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusesCmd)
+}
@@ -0,0 +1,17 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// stockCmd represents the stock command
+var stockCmd = &cobra.Command{
+	Use:   "stock",
+	Short: "Quantities of consumable components, see 'haul component stock'",
+}
+
+func init() {
+	rootCmd.AddCommand(stockCmd)
+}
@@ -0,0 +1,28 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// stockLowCmd represents the stockLow command
+var stockLowCmd = &cobra.Command{
+	Use:   "low",
+	Short: "Prints the components whose quantity is at or under their low stock threshold",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		levels, err := newClient().LowStock(context.Background())
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(levels)
+	},
+}
+
+func init() {
+	stockCmd.AddCommand(stockLowCmd)
+}
@@ -0,0 +1,33 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// tagsCmd represents the tags command
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Print the tags in use, with their values and how many objects have them",
+	Long: `Print the tags in use by components, assemblies and kits.
+
+Tags of the form key=value are grouped by key, with the number of objects
+having each value, so that existing keys can be reused. Objects with a given
+value are listed with '--filter tag.<key>=<value>'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		catalog, err := newClient().TagCatalog(context.Background())
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(catalog)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -0,0 +1,66 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// tagsApplyCmd represents the tagsApply command
+var tagsApplyCmd = &cobra.Command{
+	Use:   "apply --filter KEY=VALUE... [--add TAG...] [--remove TAG...]",
+	Short: "Add and remove tags on every object matching the filters",
+	Example: `Tag every RAM stick as fragile, without changing anything
+
+    $ haul tags apply --filter tag.type=ram --add fragile --dry-run
+
+Replace "size=8GB" by "size=8gb" on components only
+
+    $ haul tags apply --filter tag=size=8GB --kind component --remove size=8GB --add size=8gb`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getFilter(cmd)
+		if err != nil {
+			fatal(err)
+		}
+
+		var apply types.TagApply
+
+		if apply.Add, err = cmd.Flags().GetStringArray("add"); err != nil {
+			fatal(err)
+		}
+
+		if apply.Remove, err = cmd.Flags().GetStringArray("remove"); err != nil {
+			fatal(err)
+		}
+
+		if apply.Kinds, err = cmd.Flags().GetStringArray("kind"); err != nil {
+			fatal(err)
+		}
+
+		if apply.DryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+			fatal(err)
+		}
+
+		result, err := newClient().ApplyTags(context.Background(), filter, apply)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	},
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsApplyCmd)
+
+	addFilterFlags(tagsApplyCmd)
+
+	tagsApplyCmd.Flags().StringArray("add", nil, "Tag to add. Can be repeated.")
+	tagsApplyCmd.Flags().StringArray("remove", nil, "Tag to remove. Can be repeated.")
+	tagsApplyCmd.Flags().StringArray("kind", nil, "Only change objects of kind { component | assembly | kit | location }. Can be repeated.")
+	tagsApplyCmd.Flags().Bool("dry-run", false, "Only print the objects that would be changed")
+}
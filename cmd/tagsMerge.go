@@ -0,0 +1,54 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// tagsMergeCmd represents the tagsMerge command
+var tagsMergeCmd = &cobra.Command{
+	Use:   "merge TAG... --into TAG",
+	Short: "Replace several tags by a single one on every object",
+	Example: `Replace "mfr=generic" and "maker=generic" by "manufacturer=generic"
+
+    $ haul tags merge mfr=generic maker=generic --into manufacturer=generic`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		into, err := cmd.Flags().GetString("into")
+		if err != nil {
+			fatal(err)
+		}
+
+		if into == "" {
+			fatal(errors.New("--into is required"))
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			fatal(err)
+		}
+
+		result, err := newClient().MergeTags(context.Background(), types.TagMerge{
+			From:   args,
+			To:     into,
+			DryRun: dryRun,
+		})
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	},
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsMergeCmd)
+
+	tagsMergeCmd.Flags().String("into", "", "Tag replacing the merged tags")
+	tagsMergeCmd.Flags().Bool("dry-run", false, "Only print the objects that would be changed")
+}
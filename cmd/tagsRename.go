@@ -0,0 +1,58 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// tagsRenameCmd represents the tagsRename command
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename OLD NEW",
+	Short: "Rename a tag on every object",
+	Long: `Rename the tag OLD to NEW on every component, assembly and kit.
+
+With --key, OLD and NEW are keys of structured tags, and every OLD=VALUE tag
+is renamed to NEW=VALUE.`,
+	Example: `Rename the tag "mfr=generic" to "manufacturer=generic"
+
+    $ haul tags rename mfr=generic manufacturer=generic
+
+Rename every "mfr=" tag to "manufacturer=", keeping its value, without changing anything
+
+    $ haul tags rename --key --dry-run mfr manufacturer`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := cmd.Flags().GetBool("key")
+		if err != nil {
+			fatal(err)
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			fatal(err)
+		}
+
+		result, err := newClient().RenameTag(context.Background(), types.TagRename{
+			From:   args[0],
+			To:     args[1],
+			Key:    key,
+			DryRun: dryRun,
+		})
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	},
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsRenameCmd)
+
+	tagsRenameCmd.Flags().Bool("key", false, "Rename the key of structured tags, keeping their values")
+	tagsRenameCmd.Flags().Bool("dry-run", false, "Only print the objects that would be changed")
+}
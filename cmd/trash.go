@@ -0,0 +1,17 @@
+/*
+ */
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// trashCmd represents the trash command
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Deleted objects, which can be restored until they are purged",
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+}
@@ -0,0 +1,42 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/cli"
+	"github.com/spf13/cobra"
+)
+
+// trashListCmd represents the trashList command
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Prints the objects in the trash, from the most recently deleted",
+	Args:    cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := cli.New()
+
+		output, err := rootCmd.PersistentFlags().GetString("output")
+		if err != nil {
+			fatal(err)
+		}
+
+		client.OutputStyle = output
+
+		trash, err := newClient().Trash(context.Background())
+		if err != nil {
+			fatal(err)
+		}
+
+		err = client.OutputObject(trash)
+		if err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	trashCmd.AddCommand(trashListCmd)
+}
@@ -0,0 +1,38 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// trashPurgeCmd represents the trashPurge command
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently deletes the objects in the trash",
+	Long: `Permanently deletes the objects that stayed in the trash longer than the retention of the server ('server.trash.retention').
+
+The server also purges these objects on its own. Use --all to purge every object in the trash now.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			fatal(err)
+		}
+
+		result, err := newClient().PurgeTrash(context.Background(), all)
+		if err != nil {
+			fatal(err)
+		}
+
+		outputObject(result)
+	},
+}
+
+func init() {
+	trashCmd.AddCommand(trashPurgeCmd)
+
+	trashPurgeCmd.Flags().Bool("all", false, "Purge every object in the trash, whatever the retention")
+}
@@ -0,0 +1,53 @@
+/*
+ */
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// trashRestoreCmd represents the trashRestore command
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore OBJECT_ID...",
+	Short: "Restores objects identified by one or more OBJECT_ID from the trash",
+	Long: `Restores objects identified by one or more OBJECT_ID from the trash.
+
+The objects deleted along with an object by 'delete --cascade' are restored with it.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		api := newClient()
+
+		trash, err := api.Trash(context.Background())
+		if err != nil {
+			fatal(err)
+		}
+
+		trashed := map[string]types.TrashedObject{}
+		for _, object := range trash.Objects {
+			trashed[object.ID.Hex()] = object
+		}
+
+		for _, arg := range args {
+			object, ok := trashed[arg]
+			if !ok {
+				log.Fatalf("Object %s is not in the trash", arg)
+			}
+
+			result, err := api.Restore(context.Background(), client.CollectionKind(object.Collection), object.ID)
+			if err != nil {
+				fatal(err)
+			}
+
+			outputObject(result)
+		}
+	},
+}
+
+func init() {
+	trashCmd.AddCommand(trashRestoreCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+
+	"codeberg.org/haulproject/haul/client"
+	"github.com/spf13/cobra"
+)
+
+// addTreeFlags adds the flags read by printTree to a tree command.
+func addTreeFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("depth", 0, "Number of levels printed under the object, 0 for every level")
+}
+
+// printTree prints the tree of the object of kind identified by arg.
+func printTree(cmd *cobra.Command, kind client.Kind, arg string) {
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		fatal(err)
+	}
+
+	tree, err := newClient().Tree(context.Background(), kind, objectID(arg), depth)
+	if err != nil {
+		fatal(err)
+	}
+
+	outputObject(tree)
+}
+
+// printChildren prints the objects targeting the object of kind identified
+// by arg.
+func printChildren(kind client.Kind, arg string) {
+	children, err := newClient().Children(context.Background(), kind, objectID(arg))
+	if err != nil {
+		fatal(err)
+	}
+
+	outputObject(children)
+}
+
+// printAncestors prints the targets of the object of kind identified by
+// arg, up to the kit containing it.
+func printAncestors(kind client.Kind, arg string) {
+	ancestors, err := newClient().Ancestors(context.Background(), kind, objectID(arg))
+	if err != nil {
+		fatal(err)
+	}
+
+	outputObject(ancestors)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"codeberg.org/haulproject/haul/client"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/spf13/cobra"
+)
+
+// conflictRetries is the number of times a change is retried when the object
+// was modified by someone else at the same time.
+const conflictRetries = 3
+
+// addIfMatchFlag adds the flag read by changeIfMatch to a command changing an
+// object.
+func addIfMatchFlag(cmd *cobra.Command) {
+	cmd.Flags().Int64("if-match", 0, `Only apply the change if the object is still at this version (its ETag, see 'read -o json'),
+and fail if it was modified since. Without it, changes conflicting with a concurrent one are retried`)
+}
+
+// changeIfMatch applies change to an object, honouring the flag added by
+// addIfMatchFlag, and returns its result.
+//
+// Without --if-match, the change is retried when the server reports that the
+// object was modified at the same time (412 Precondition Failed).
+func changeIfMatch(cmd *cobra.Command, change func(opts ...client.Option) (*types.UpdateResult, error)) (*types.UpdateResult, error) {
+	opts := forceOptions(cmd)
+
+	retries := conflictRetries
+
+	if cmd.Flags().Changed("if-match") {
+		version, err := cmd.Flags().GetInt64("if-match")
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, client.IfMatch(version))
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := change(opts...)
+		if !client.IsPreconditionFailed(err) {
+			return result, err
+		}
+
+		if attempt >= retries {
+			var e *client.Error
+			errors.As(err, &e)
+
+			return nil, fmt.Errorf("%w (current ETag: %s)", err, e.ETag)
+		}
+	}
+}
@@ -0,0 +1,716 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BoltStore is a Store backed by an embedded bbolt database file.
+//
+// Every collection is a bucket, in which documents are stored as bson keyed
+// by their ObjectID.
+type BoltStore struct {
+	db *bolt.DB
+
+	// unique are the unique keys checked on every write, see EnsureUnique
+	unique uniqueKeys
+}
+
+// NewBoltStore opens, or creates, the bbolt database file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	if path == "" {
+		return nil, errors.New("'server.storage.path' cannot be empty when using the bolt storage driver")
+	}
+
+	b, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltStore{db: b}, nil
+}
+
+// Close closes the bbolt database file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) Ping(ctx context.Context) (bson.M, error) {
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{"ok": 1}, nil
+}
+
+// view runs fn in a read-only transaction, unless ctx is already done.
+func (s *BoltStore) view(ctx context.Context, fn func(*bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.db.View(fn)
+}
+
+// update runs fn in a read-write transaction, unless ctx is already done.
+func (s *BoltStore) update(ctx context.Context, fn func(*bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.db.Update(fn)
+}
+
+// Create
+
+func (s *BoltStore) CreateComponent(ctx context.Context, component types.Component) (*mongo.InsertOneResult, error) {
+	if component.Name == "" {
+		return nil, errors.New("component.Name cannot be empty")
+	}
+
+	object, err := newObject(component)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "components", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertOneResult{InsertedID: ids[0]}, nil
+}
+
+func (s *BoltStore) CreateComponents(ctx context.Context, components types.Components) (*mongo.InsertManyResult, error) {
+	data := make([]interface{}, len(components.Components))
+	for i, component := range components.Components {
+		if component.Name == "" {
+			return nil, errors.New("component.Name cannot be empty")
+		}
+		data[i] = component
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "components", objects...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertManyResult{InsertedIDs: ids}, nil
+}
+
+func (s *BoltStore) CreateAssembly(ctx context.Context, assembly types.Assembly) (*mongo.InsertOneResult, error) {
+	if assembly.Name == "" {
+		return nil, errors.New("assembly.Name cannot be empty")
+	}
+
+	object, err := newObject(assembly)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "assemblies", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertOneResult{InsertedID: ids[0]}, nil
+}
+
+func (s *BoltStore) CreateAssemblies(ctx context.Context, assemblies types.Assemblies) (*mongo.InsertManyResult, error) {
+	data := make([]interface{}, len(assemblies.Assemblies))
+	for i, assembly := range assemblies.Assemblies {
+		if assembly.Name == "" {
+			return nil, errors.New("assembly.Name cannot be empty")
+		}
+		data[i] = assembly
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "assemblies", objects...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertManyResult{InsertedIDs: ids}, nil
+}
+
+func (s *BoltStore) CreateKit(ctx context.Context, kit types.Kit) (*mongo.InsertOneResult, error) {
+	if kit.Name == "" {
+		return nil, errors.New("kit.Name cannot be empty")
+	}
+
+	object, err := newObject(kit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "kits", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertOneResult{InsertedID: ids[0]}, nil
+}
+
+func (s *BoltStore) CreateKits(ctx context.Context, kits types.Kits) (*mongo.InsertManyResult, error) {
+	data := make([]interface{}, len(kits.Kits))
+	for i, kit := range kits.Kits {
+		if kit.Name == "" {
+			return nil, errors.New("kit.Name cannot be empty")
+		}
+		data[i] = kit
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.insert(ctx, "kits", objects...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertManyResult{InsertedIDs: ids}, nil
+}
+
+func (s *BoltStore) InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	if versionedCollections[collection] {
+		var err error
+		if documents, err = newObjects(documents); err != nil {
+			return nil, err
+		}
+	}
+
+	ids, err := s.insert(ctx, collection, documents...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.InsertManyResult{InsertedIDs: ids}, nil
+}
+
+// insert stores documents in collection, adding an "_id" to the documents
+// that do not have one, and returns the ids of the inserted documents.
+func (s *BoltStore) insert(ctx context.Context, collection string, documents ...interface{}) ([]interface{}, error) {
+	var ids []interface{}
+
+	err := s.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(collection))
+		if err != nil {
+			return err
+		}
+
+		ds := make([]bson.D, len(documents))
+
+		for i, document := range documents {
+			d, err := toD(document)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := lookup(d, "_id").(primitive.ObjectID); !ok {
+				d = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, d...)
+			}
+
+			ds[i] = d
+		}
+
+		if err := s.unique.batchDuplicate(collection, ds); err != nil {
+			return err
+		}
+
+		for _, d := range ds {
+			id := lookup(d, "_id").(primitive.ObjectID)
+
+			if bucket.Get(id[:]) != nil {
+				return fmt.Errorf("Duplicate key: document with _id %s already exists in %s", id.Hex(), collection)
+			}
+
+			if err := s.unique.duplicate(collection, d, bucketFind(bucket)); err != nil {
+				return err
+			}
+
+			raw, err := bson.Marshal(d)
+			if err != nil {
+				return err
+			}
+
+			if err := bucket.Put(id[:], raw); err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Read
+
+func (s *BoltStore) ReadFromID(ctx context.Context, collection string, id primitive.ObjectID) (bson.M, error) {
+	var result bson.M
+
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return mongo.ErrNoDocuments
+		}
+
+		raw := bucket.Get(id[:])
+		if raw == nil {
+			return mongo.ErrNoDocuments
+		}
+
+		return bson.Unmarshal(raw, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *BoltStore) ReadAll(ctx context.Context, collection string, filter bson.D, opts *ReadOptions) ([]*bson.M, error) {
+	var results []*bson.M
+
+	query, err := normalizeQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, raw []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var result bson.M
+			if err := bson.Unmarshal(raw, &result); err != nil {
+				return err
+			}
+
+			matched, err := match(result, query)
+			if err != nil || !matched {
+				return err
+			}
+
+			results = append(results, &result)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		return results, nil
+	}
+
+	if len(opts.Sort) > 0 {
+		sort.SliceStable(results, func(i, j int) bool {
+			for _, field := range opts.Sort {
+				a, _ := lookupPath(*results[i], field.Key)
+				b, _ := lookupPath(*results[j], field.Key)
+
+				order := compareForSort(a, b)
+				if direction, _ := toFloat(field.Value); direction < 0 {
+					order = -order
+				}
+
+				if order != 0 {
+					return order < 0
+				}
+			}
+
+			return false
+		})
+	}
+
+	if opts.Limit > 0 && int64(len(results)) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	if len(opts.Projection) > 0 {
+		for _, result := range results {
+			projected := bson.M{"_id": (*result)["_id"]}
+			for _, field := range opts.Projection {
+				if value, ok := (*result)[field]; ok {
+					projected[field] = value
+				}
+			}
+			*result = projected
+		}
+	}
+
+	return results, nil
+}
+
+func (s *BoltStore) Count(ctx context.Context, collection string, filter bson.D) (int64, error) {
+	results, err := s.ReadAll(ctx, collection, filter, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(results)), nil
+}
+
+// Delete
+
+func (s *BoltStore) DeleteFromID(ctx context.Context, collection string, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	return s.DeleteMany(ctx, collection, bson.D{{Key: "_id", Value: id}})
+}
+
+func (s *BoltStore) DeleteMany(ctx context.Context, collection string, filter bson.D) (*mongo.DeleteResult, error) {
+	result := &mongo.DeleteResult{}
+
+	err := s.updateWhere(ctx, collection, filter, func(bucket *bolt.Bucket, key []byte, _ bson.D) error {
+		result.DeletedCount++
+		return bucket.Delete(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Update
+
+func (s *BoltStore) UpdateFromID(ctx context.Context, collection string, id primitive.ObjectID, data bson.D) (*mongo.UpdateResult, error) {
+	return s.UpdateMany(ctx, collection, bson.D{{Key: "_id", Value: id}}, data)
+}
+
+func (s *BoltStore) UpdateMany(ctx context.Context, collection string, filter bson.D, data bson.D) (*mongo.UpdateResult, error) {
+	// Empty name validation
+
+	if err := validateUpdate(data); err != nil {
+		return nil, err
+	}
+
+	data = withVersionIncrement(collection, data)
+
+	result := &mongo.UpdateResult{}
+
+	err := s.updateWhere(ctx, collection, filter, func(bucket *bolt.Bucket, key []byte, document bson.D) error {
+		result.MatchedCount++
+
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return err
+		}
+
+		before := document
+
+		document, err = applyUpdate(append(bson.D{}, document...), data)
+		if err != nil {
+			return err
+		}
+
+		updated, err := bson.Marshal(document)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(raw, updated) {
+			return nil
+		}
+
+		if s.unique.changed(collection, before, document) {
+			if err := s.unique.duplicate(collection, document, bucketFind(bucket)); err != nil {
+				return err
+			}
+		}
+
+		result.ModifiedCount++
+		return bucket.Put(key, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Constraints
+
+func (s *BoltStore) EnsureUnique(ctx context.Context, collection, key string) error {
+	// The documents are checked in the same transaction as the key is made
+	// unique, so that no write happens in between
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		var documents []*bson.M
+
+		if bucket := tx.Bucket([]byte(collection)); bucket != nil {
+			err := bucket.ForEach(func(_, raw []byte) error {
+				var document bson.M
+				if err := bson.Unmarshal(raw, &document); err != nil {
+					return err
+				}
+
+				if !IsTrashed(document) {
+					documents = append(documents, &document)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := existingDuplicate(collection, key, documents); err != nil {
+			return err
+		}
+
+		s.unique = s.unique.add(collection, key)
+
+		return nil
+	})
+}
+
+func (s *BoltStore) DropUnique(ctx context.Context, collection, key string) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		s.unique = s.unique.remove(collection, key)
+		return nil
+	})
+}
+
+// bucketFind returns the function returning the first document of bucket
+// selected by a query, or nil, see uniqueKeys.duplicate.
+func bucketFind(bucket *bolt.Bucket) func(query bson.D) (bson.M, error) {
+	return func(query bson.D) (bson.M, error) {
+		query, err := normalizeQuery(query)
+		if err != nil {
+			return nil, err
+		}
+
+		var found bson.M
+
+		err = bucket.ForEach(func(_, raw []byte) error {
+			if found != nil {
+				return nil
+			}
+
+			var document bson.M
+			if err := bson.Unmarshal(raw, &document); err != nil {
+				return err
+			}
+
+			matched, err := match(document, query)
+			if matched {
+				found = document
+			}
+
+			return err
+		})
+
+		return found, err
+	}
+}
+
+// updateWhere calls fn, in a single read-write transaction, for every
+// document of collection selected by the query filter.
+func (s *BoltStore) updateWhere(ctx context.Context, collection string, filter bson.D, fn func(bucket *bolt.Bucket, key []byte, document bson.D) error) error {
+	query, err := normalizeQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+
+		// A lookup by id does not need to scan the bucket
+		var keys [][]byte
+
+		if id, ok := lookup(query, "_id").(primitive.ObjectID); ok && len(query) == 1 {
+			if bucket.Get(id[:]) != nil {
+				keys = append(keys, id[:])
+			}
+		} else {
+			err := bucket.ForEach(func(key, raw []byte) error {
+				var document bson.M
+				if err := bson.Unmarshal(raw, &document); err != nil {
+					return err
+				}
+
+				matched, err := match(document, query)
+				if err != nil || !matched {
+					return err
+				}
+
+				// Keys are only valid during the transaction, and the
+				// bucket cannot be modified while iterating over it
+				keys = append(keys, append([]byte(nil), key...))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, key := range keys {
+			var document bson.D
+			if err := bson.Unmarshal(bucket.Get(key), &document); err != nil {
+				return err
+			}
+
+			if err := fn(bucket, key, document); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// applyUpdate returns document modified by the update operators in update.
+//
+// Supported operators are "$set", "$unset" and "$inc".
+func applyUpdate(document bson.D, update bson.D) (bson.D, error) {
+	for _, operator := range update {
+		fields, err := toD(operator.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch operator.Key {
+		case "$set":
+			for _, field := range fields {
+				document = set(document, field.Key, field.Value)
+			}
+		case "$unset":
+			for _, field := range fields {
+				document = unset(document, field.Key)
+			}
+		case "$inc":
+			for _, field := range fields {
+				value, err := increment(lookup(document, field.Key), field.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				document = set(document, field.Key, value)
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported update operator '%s'", operator.Key)
+		}
+	}
+
+	return document, nil
+}
+
+// increment returns value incremented by amount, as by "$inc": a missing
+// value counts as 0, and integers stay integers.
+func increment(value, amount interface{}) (interface{}, error) {
+	a, ok := toFloat(amount)
+	if !ok {
+		return nil, fmt.Errorf("Cannot increment by non-numeric value %v", amount)
+	}
+
+	if value == nil {
+		return amount, nil
+	}
+
+	v, ok := toFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("Cannot increment non-numeric value %v", value)
+	}
+
+	_, floatValue := value.(float64)
+	_, floatAmount := amount.(float64)
+
+	if floatValue || floatAmount {
+		return v + a, nil
+	}
+
+	return int64(v + a), nil
+}
+
+// toD converts a document (struct, bson.M, bson.D, ...) to a bson.D.
+func toD(document interface{}) (bson.D, error) {
+	if d, ok := document.(bson.D); ok {
+		return d, nil
+	}
+
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var d bson.D
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// lookup returns the value of key in document, or nil if it is absent.
+func lookup(document bson.D, key string) interface{} {
+	for _, element := range document {
+		if element.Key == key {
+			return element.Value
+		}
+	}
+
+	return nil
+}
+
+// set returns document with key set to value, appending key if it is absent.
+func set(document bson.D, key string, value interface{}) bson.D {
+	for i, element := range document {
+		if element.Key == key {
+			document[i].Value = value
+			return document
+		}
+	}
+
+	return append(document, bson.E{Key: key, Value: value})
+}
+
+// unset returns document without key.
+func unset(document bson.D, key string) bson.D {
+	for i, element := range document {
+		if element.Key == key {
+			return append(document[:i], document[i+1:]...)
+		}
+	}
+
+	return document
+}
@@ -1,7 +1,13 @@
 /*
 	Package db implements database operations for the haul server.
 
-The database used is mongodb.
+Operations are exposed through the Store interface, which is implemented by
+the following storage backends:
+
+  - mongo: a MongoDB server, see MongoStore
+  - bolt: an embedded bbolt database file, see BoltStore
+
+The backend is selected with 'server.storage.driver'.
 */
 package db
 
@@ -9,457 +15,122 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"time"
 
 	"codeberg.org/haulproject/haul/types"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func Ping(uri string) (bson.M, error) {
-	// MongoDB connection
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(viper.GetString("mongo.uri")).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	var result bson.M
-
-	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-// Create
-
-func CreateComponent(component types.Component) (*mongo.InsertOneResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-	coll := client.Database("haul").Collection("components")
-
-	if component.Name == "" {
-		return nil, errors.New("component.Name cannot be empty")
-	}
-
-	result, err := coll.InsertOne(ctx, component)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-func CreateComponents(components types.Components) (*mongo.InsertManyResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	coll := client.Database("haul").Collection("components")
-
-	for _, component := range components.Components {
-		if component.Name == "" {
-			return nil, errors.New("component.Name cannot be empty")
-		}
-	}
-	data := make([]interface{}, len(components.Components))
-	for i := range components.Components {
-		data[i] = components.Components[i]
-	}
-
-	result, err := coll.InsertMany(ctx, data)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-func CreateAssembly(assembly types.Assembly) (*mongo.InsertOneResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-	coll := client.Database("haul").Collection("assemblies")
-
-	if assembly.Name == "" {
-		return nil, errors.New("assembly.Name cannot be empty")
-	}
-
-	result, err := coll.InsertOne(ctx, assembly)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-func CreateAssemblies(assemblies types.Assemblies) (*mongo.InsertManyResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	coll := client.Database("haul").Collection("assemblies")
-
-	for _, assembly := range assemblies.Assemblies {
-		if assembly.Name == "" {
-			return nil, errors.New("assembly.Name cannot be empty")
-		}
-	}
-	data := make([]interface{}, len(assemblies.Assemblies))
-	for i := range assemblies.Assemblies {
-		data[i] = assemblies.Assemblies[i]
-	}
-
-	result, err := coll.InsertMany(ctx, data)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-func CreateKit(kit types.Kit) (*mongo.InsertOneResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-	coll := client.Database("haul").Collection("kits")
-
-	if kit.Name == "" {
-		return nil, errors.New("kit.Name cannot be empty")
-	}
-
-	result, err := coll.InsertOne(ctx, kit)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-func CreateKits(kits types.Kits) (*mongo.InsertManyResult, error) {
-	mongoUri := viper.GetString("mongo.uri")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	coll := client.Database("haul").Collection("kits")
-
-	for _, kit := range kits.Kits {
-		if kit.Name == "" {
-			return nil, errors.New("kit.Name cannot be empty")
-		}
-	}
-	data := make([]interface{}, len(kits.Kits))
-	for i := range kits.Kits {
-		data[i] = kits.Kits[i]
-	}
-
-	result, err := coll.InsertMany(ctx, data)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-// Read
-
-func ReadFromID(collection string, id primitive.ObjectID) (bson.M, error) {
-
-	// MongoDB connection
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(viper.GetString("mongo.uri")).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
+const (
+	DriverMongo = "mongo" // Default
+	DriverBolt  = "bolt"
+)
 
-	var result bson.M
+// Store is implemented by every storage backend of the haul server.
+//
+// Documents are exchanged as bson, and updates use the mongodb update
+// operators (e.g. "$set"), whatever the backend.
+//
+// Every operation takes the context of the request it serves, so that
+// cancellation and deadlines reach the backend.
+type Store interface {
+	// Ping confirms that the backend is reachable.
+	Ping(ctx context.Context) (bson.M, error)
 
-	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	// Create
 
-	err = client.Database("haul").Collection(collection).FindOne(ctx, filter).Decode(&result)
-	if err != nil {
-		return result, err
-	}
+	CreateComponent(ctx context.Context, component types.Component) (*mongo.InsertOneResult, error)
+	CreateComponents(ctx context.Context, components types.Components) (*mongo.InsertManyResult, error)
+	CreateAssembly(ctx context.Context, assembly types.Assembly) (*mongo.InsertOneResult, error)
+	CreateAssemblies(ctx context.Context, assemblies types.Assemblies) (*mongo.InsertManyResult, error)
+	CreateKit(ctx context.Context, kit types.Kit) (*mongo.InsertOneResult, error)
+	CreateKits(ctx context.Context, kits types.Kits) (*mongo.InsertManyResult, error)
 
-	return result, nil
+	// InsertMany inserts documents of any shape in collection.
+	InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error)
 
-}
+	// Read
 
-func ReadAll(collection string) ([]*bson.M, error) {
-	// MongoDB connection
+	// ReadFromID returns mongo.ErrNoDocuments if no document matches id.
+	ReadFromID(ctx context.Context, collection string, id primitive.ObjectID) (bson.M, error)
+	// ReadAll returns the documents selected by the query filter, which may
+	// be nil to select every document. See FilterQuery.
+	//
+	// opts may be nil to return every selected document, in no particular
+	// order.
+	ReadAll(ctx context.Context, collection string, filter bson.D, opts *ReadOptions) ([]*bson.M, error)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	// Count returns the number of documents selected by the query filter.
+	Count(ctx context.Context, collection string, filter bson.D) (int64, error)
 
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(viper.GetString("mongo.uri")).SetServerAPIOptions(serverAPI)
+	// Update
 
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
+	UpdateFromID(ctx context.Context, collection string, id primitive.ObjectID, data bson.D) (*mongo.UpdateResult, error)
 
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
+	// UpdateMany applies data to every document selected by the query filter.
+	UpdateMany(ctx context.Context, collection string, filter bson.D, data bson.D) (*mongo.UpdateResult, error)
 
-	var components []*bson.M
+	// Delete
 
-	filter := bson.D{primitive.E{}}
+	DeleteFromID(ctx context.Context, collection string, id primitive.ObjectID) (*mongo.DeleteResult, error)
 
-	cursor, err := client.Database("haul").Collection(collection).Find(ctx, filter)
-	if err != nil {
-		return nil, err
+	// DeleteMany deletes every document selected by the query filter.
+	DeleteMany(ctx context.Context, collection string, filter bson.D) (*mongo.DeleteResult, error)
 
-	}
+	// Constraints
 
-	for cursor.Next(ctx) {
-		var component bson.M
-		err := cursor.Decode(&component)
-		if err != nil {
-			return nil, err
-		}
-		components = append(components, &component)
-	}
-	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
+	// EnsureUnique makes the values of key, types.UniqueKeyName, "tag.<key>"
+	// or "field.<name>", unique among the documents of collection that are
+	// not in the trash. The writes giving a document a value held by another
+	// return a *DuplicateError, as does EnsureUnique if documents already
+	// share a value. Ensuring a key already unique does nothing.
+	EnsureUnique(ctx context.Context, collection, key string) error
 
-	cursor.Close(ctx)
+	// DropUnique lets the documents of collection share the values of key
+	// again, see EnsureUnique.
+	DropUnique(ctx context.Context, collection, key string) error
 
-	return components, nil
+	// Close releases the resources held by the Store.
+	Close() error
 }
 
-// Delete
-
-func DeleteFromID(collection string, id primitive.ObjectID) (*mongo.DeleteResult, error) {
-
-	// MongoDB connection
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(viper.GetString("mongo.uri")).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-
-	result, err := client.Database("haul").Collection(collection).DeleteOne(ctx, filter)
-	if err != nil {
-		return nil, err
+// Open returns the Store selected by 'server.storage.driver'.
+func Open(ctx context.Context) (Store, error) {
+	switch driver := viper.GetString("server.storage.driver"); driver {
+	case "", DriverMongo:
+		return NewMongoStore(ctx, viper.GetString("mongo.uri"))
+	case DriverBolt:
+		return NewBoltStore(viper.GetString("server.storage.path"))
+	default:
+		return nil, fmt.Errorf("Unknown storage driver '%s', must be one of { %s | %s }", driver, DriverMongo, DriverBolt)
 	}
-
-	return result, nil
-
 }
 
-// Update
-
-func UpdateFromID(collection string, id primitive.ObjectID, data bson.D) (*mongo.UpdateResult, error) {
-	// Empty name validation
+// Validation
 
+func validateUpdate(data bson.D) error {
 	dataBytes, err := bson.Marshal(data)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	var component map[string]map[string]interface{}
 
 	err = bson.Unmarshal(dataBytes, &component)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Validation for trying to empty the name
 	for _, element := range component {
 		for key, value := range element {
 			if key == "name" && value == "" {
-				return nil, errors.New("Cannot insert empty string into Component.Name")
+				return errors.New("Cannot insert empty string into Component.Name")
 			}
 		}
 	}
 
-	// MongoDB connection
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// Use the SetServerAPIOptions() method to set the Stable API version to 1
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(viper.GetString("mongo.uri")).SetServerAPIOptions(serverAPI)
-
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(ctx, opts)
-
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err = client.Disconnect(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-		}
-	}()
-
-	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-
-	result, err := client.Database("haul").Collection(collection).UpdateOne(ctx, filter, data)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-
+	return nil
 }
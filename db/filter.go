@@ -0,0 +1,198 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FilterQuery returns the query selecting the documents described by filter.
+// The custom fields of filter are those of schema, which is nil when the
+// documents are of several kinds.
+//
+// An empty filter returns an empty query, which selects every document.
+func FilterQuery(filter types.Filter, schema *types.Schema) (bson.D, error) {
+	switch filter.Match {
+	case "", types.FilterMatchAll, types.FilterMatchAny:
+	default:
+		return nil, fmt.Errorf("Invalid match '%s', must be one of { %s | %s }", filter.Match, types.FilterMatchAll, types.FilterMatchAny)
+	}
+
+	var conditions bson.A
+
+	if filter.Name != "" {
+		conditions = append(conditions, bson.D{{Key: "name", Value: filter.Name}})
+	}
+
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, bson.D{{Key: "name", Value: primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(filter.NamePrefix),
+		}}})
+	}
+
+	if filter.NameRegex != "" {
+		if _, err := regexp.Compile(filter.NameRegex); err != nil {
+			return nil, fmt.Errorf("Invalid name_regex: %s", err)
+		}
+
+		conditions = append(conditions, bson.D{{Key: "name", Value: primitive.Regex{
+			Pattern: filter.NameRegex,
+		}}})
+	}
+
+	if len(filter.Status) > 0 {
+		conditions = append(conditions, bson.D{{Key: "status", Value: bson.D{
+			{Key: "$in", Value: filter.Status},
+		}}})
+	}
+
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, bson.D{{Key: "tags", Value: tag}})
+	}
+
+	for _, tag := range filter.NotTags {
+		conditions = append(conditions, bson.D{{Key: "tags", Value: bson.D{
+			{Key: "$ne", Value: tag},
+		}}})
+	}
+
+	keys := make([]string, 0, len(filter.TagValues))
+	for key := range filter.TagValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("Invalid %s filter: the key is empty", types.TagValuesPrefix)
+		}
+
+		condition, err := tagValuesCondition(key, filter.TagValues[key])
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	names := make([]string, 0, len(filter.FieldValues))
+	for name := range filter.FieldValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		condition, err := fieldValuesCondition(schema, name, filter.FieldValues[name])
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if filter.Target != "" {
+		target, err := primitive.ObjectIDFromHex(filter.Target)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target: %s", err)
+		}
+
+		conditions = append(conditions, bson.D{{Key: "target", Value: target}})
+	}
+
+	if filter.Untargeted {
+		conditions = append(conditions, bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "target", Value: primitive.NilObjectID}},
+			bson.D{{Key: "target", Value: bson.D{{Key: "$exists", Value: false}}}},
+		}}})
+	}
+
+	if filter.Location != "" {
+		location, err := primitive.ObjectIDFromHex(filter.Location)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid location: %s", err)
+		}
+
+		conditions = append(conditions, bson.D{{Key: "location", Value: location}})
+	}
+
+	if len(conditions) == 0 {
+		return bson.D{}, nil
+	}
+
+	if filter.Match == types.FilterMatchAny {
+		return bson.D{{Key: "$or", Value: conditions}}, nil
+	}
+
+	return bson.D{{Key: "$and", Value: conditions}}, nil
+}
+
+// tagValuesCondition returns the condition selecting the documents with a tag
+// of key with any of values, see types.Filter.TagValues.
+func tagValuesCondition(key string, values []string) (bson.D, error) {
+	tags := bson.A{}
+
+	for _, value := range values {
+		if value == "*" {
+			return bson.D{{Key: "tags", Value: primitive.Regex{
+				Pattern: "^" + regexp.QuoteMeta(types.Tag(key, "")),
+			}}}, nil
+		}
+
+		tags = append(tags, types.Tag(key, value))
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("Invalid %s%s filter: no value", types.TagValuesPrefix, key)
+	}
+
+	return bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: tags}}}}, nil
+}
+
+// fieldValuesCondition returns the condition selecting the documents whose
+// custom field of schema named by key has any of values, or is within the
+// bound of key, see types.Filter.FieldValues.
+func fieldValuesCondition(schema *types.Schema, key string, values []string) (bson.D, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("Invalid %s%s filter: custom fields can only be filtered for a single kind", types.FieldValuesPrefix, key)
+	}
+
+	name, operator := key, "$in"
+
+	if strings.HasSuffix(key, ".min") {
+		name, operator = strings.TrimSuffix(key, ".min"), "$gte"
+	} else if strings.HasSuffix(key, ".max") {
+		name, operator = strings.TrimSuffix(key, ".max"), "$lte"
+	}
+
+	field, ok := schema.Field(name)
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s%s filter: unknown field, must be one of { %s }", types.FieldValuesPrefix, key, strings.Join(schema.Names(), " | "))
+	}
+
+	parsed := bson.A{}
+
+	for _, value := range values {
+		p, err := field.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s%s filter: %s", types.FieldValuesPrefix, key, err)
+		}
+
+		parsed = append(parsed, p)
+	}
+
+	switch {
+	case len(parsed) == 0:
+		return nil, fmt.Errorf("Invalid %s%s filter: no value", types.FieldValuesPrefix, key)
+	case operator == "$in":
+		return bson.D{{Key: "fields." + name, Value: bson.D{{Key: "$in", Value: parsed}}}}, nil
+	case len(parsed) > 1:
+		return nil, fmt.Errorf("Invalid %s%s filter: a bound has a single value", types.FieldValuesPrefix, key)
+	default:
+		return bson.D{{Key: "fields." + name, Value: bson.D{{Key: operator, Value: parsed[0]}}}}, nil
+	}
+}
@@ -0,0 +1,492 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HistoryCollection is the collection of the types.HistoryEntry recorded by
+// HistoryStore.
+const HistoryCollection = "history"
+
+// historyCollections are the collections whose changes are recorded.
+var historyCollections = map[string]bool{
+	"components": true,
+	"assemblies": true,
+	"kits":       true,
+	"locations":  true,
+}
+
+// Actor identifies who makes the changes of a request.
+type Actor struct {
+	Name     string
+	RemoteIP string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, or "anonymous" if there is none.
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+
+	return Actor{Name: "anonymous"}
+}
+
+// HistoryStore is a Store recording every change made to components,
+// assemblies and kits of the wrapped Store in HistoryCollection.
+//
+// Failing to record a change is logged, but does not fail the change itself.
+type HistoryStore struct {
+	Store
+}
+
+// NewHistoryStore returns store, recording its changes.
+func NewHistoryStore(store Store) *HistoryStore {
+	return &HistoryStore{Store: store}
+}
+
+// Create
+
+func (s *HistoryStore) CreateComponent(ctx context.Context, component types.Component) (*mongo.InsertOneResult, error) {
+	result, err := s.Store.CreateComponent(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "components", result.InsertedID)
+
+	return result, nil
+}
+
+func (s *HistoryStore) CreateComponents(ctx context.Context, components types.Components) (*mongo.InsertManyResult, error) {
+	result, err := s.Store.CreateComponents(ctx, components)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "components", result.InsertedIDs...)
+
+	return result, nil
+}
+
+func (s *HistoryStore) CreateAssembly(ctx context.Context, assembly types.Assembly) (*mongo.InsertOneResult, error) {
+	result, err := s.Store.CreateAssembly(ctx, assembly)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "assemblies", result.InsertedID)
+
+	return result, nil
+}
+
+func (s *HistoryStore) CreateAssemblies(ctx context.Context, assemblies types.Assemblies) (*mongo.InsertManyResult, error) {
+	result, err := s.Store.CreateAssemblies(ctx, assemblies)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "assemblies", result.InsertedIDs...)
+
+	return result, nil
+}
+
+func (s *HistoryStore) CreateKit(ctx context.Context, kit types.Kit) (*mongo.InsertOneResult, error) {
+	result, err := s.Store.CreateKit(ctx, kit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "kits", result.InsertedID)
+
+	return result, nil
+}
+
+func (s *HistoryStore) CreateKits(ctx context.Context, kits types.Kits) (*mongo.InsertManyResult, error) {
+	result, err := s.Store.CreateKits(ctx, kits)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordCreate(ctx, "kits", result.InsertedIDs...)
+
+	return result, nil
+}
+
+func (s *HistoryStore) InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	result, err := s.Store.InsertMany(ctx, collection, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	if historyCollections[collection] {
+		s.recordCreate(ctx, collection, result.InsertedIDs...)
+	}
+
+	return result, nil
+}
+
+// Update
+
+func (s *HistoryStore) UpdateFromID(ctx context.Context, collection string, id primitive.ObjectID, data bson.D) (*mongo.UpdateResult, error) {
+	if !historyCollections[collection] {
+		return s.Store.UpdateFromID(ctx, collection, id, data)
+	}
+
+	before, err := s.snapshots(ctx, collection, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Store.UpdateFromID(ctx, collection, id, data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordUpdate(ctx, collection, before)
+
+	return result, nil
+}
+
+func (s *HistoryStore) UpdateMany(ctx context.Context, collection string, filter bson.D, data bson.D) (*mongo.UpdateResult, error) {
+	if !historyCollections[collection] {
+		return s.Store.UpdateMany(ctx, collection, filter, data)
+	}
+
+	before, err := s.snapshots(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Store.UpdateMany(ctx, collection, filter, data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordUpdate(ctx, collection, before)
+
+	return result, nil
+}
+
+// Delete
+
+func (s *HistoryStore) DeleteFromID(ctx context.Context, collection string, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	if !historyCollections[collection] {
+		return s.Store.DeleteFromID(ctx, collection, id)
+	}
+
+	before, err := s.snapshots(ctx, collection, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Store.DeleteFromID(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordDelete(ctx, collection, before)
+
+	return result, nil
+}
+
+func (s *HistoryStore) DeleteMany(ctx context.Context, collection string, filter bson.D) (*mongo.DeleteResult, error) {
+	if !historyCollections[collection] {
+		return s.Store.DeleteMany(ctx, collection, filter)
+	}
+
+	before, err := s.snapshots(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Store.DeleteMany(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordDelete(ctx, collection, before)
+
+	return result, nil
+}
+
+// Recording
+
+// snapshots returns the documents selected by filter, by id.
+func (s *HistoryStore) snapshots(ctx context.Context, collection string, filter bson.D) (map[primitive.ObjectID]bson.M, error) {
+	documents, err := s.Store.ReadAll(ctx, collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[primitive.ObjectID]bson.M, len(documents))
+
+	for _, document := range documents {
+		if id, ok := (*document)["_id"].(primitive.ObjectID); ok {
+			snapshots[id] = *document
+		}
+	}
+
+	return snapshots, nil
+}
+
+func (s *HistoryStore) recordCreate(ctx context.Context, collection string, ids ...interface{}) {
+	var entries []interface{}
+
+	for _, id := range ids {
+		id, ok := id.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+
+		after, err := s.Store.ReadFromID(ctx, collection, id)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		entries = append(entries, newHistoryEntry(ctx, collection, id, types.HistoryActionCreate, nil, after))
+	}
+
+	s.record(ctx, entries)
+}
+
+func (s *HistoryStore) recordUpdate(ctx context.Context, collection string, before map[primitive.ObjectID]bson.M) {
+	var entries []interface{}
+
+	for id, previous := range before {
+		after, err := s.Store.ReadFromID(ctx, collection, id)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		entry := newHistoryEntry(ctx, collection, id, types.HistoryActionUpdate, previous, after)
+
+		// Updates that change nothing are not recorded
+		if len(entry.Diff) == 0 {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	s.record(ctx, entries)
+}
+
+func (s *HistoryStore) recordDelete(ctx context.Context, collection string, before map[primitive.ObjectID]bson.M) {
+	var entries []interface{}
+
+	for id, previous := range before {
+		entries = append(entries, newHistoryEntry(ctx, collection, id, types.HistoryActionPurge, previous, nil))
+	}
+
+	s.record(ctx, entries)
+}
+
+func (s *HistoryStore) record(ctx context.Context, entries []interface{}) {
+	if len(entries) == 0 {
+		return
+	}
+
+	if _, err := s.Store.InsertMany(ctx, HistoryCollection, entries); err != nil {
+		log.Println("[error] Could not record history:", err)
+	}
+}
+
+// newHistoryEntry returns the entry recording the change of the object id
+// from before to after.
+//
+// An update is recorded as a types.HistoryActionTags or
+// types.HistoryActionTarget when only the tags or the target changed, and as a
+// types.HistoryActionDelete or types.HistoryActionRestore when the object only
+// moved in or out of the trash.
+func newHistoryEntry(ctx context.Context, collection string, id primitive.ObjectID, action string, before, after bson.M) types.HistoryEntry {
+	actor := ActorFrom(ctx)
+
+	diff := historyDiff(before, after)
+
+	if action == types.HistoryActionUpdate && len(diff) == 1 {
+		switch diff[0].Field {
+		case "tags":
+			action = types.HistoryActionTags
+		case "target":
+			action = types.HistoryActionTarget
+		case TrashedField:
+			action = types.HistoryActionRestore
+			if IsTrashed(after) {
+				action = types.HistoryActionDelete
+			}
+		}
+	}
+
+	return types.HistoryEntry{
+		ID:         primitive.NewObjectID(),
+		Collection: collection,
+		Object:     id,
+		Action:     action,
+		Actor:      actor.Name,
+		RemoteIP:   actor.RemoteIP,
+		Time:       time.Now().UTC(),
+		Before:     before,
+		After:      after,
+		Diff:       diff,
+	}
+}
+
+// historyDiff returns the fields that differ between before and after, sorted
+// by name.
+func historyDiff(before, after bson.M) []types.FieldChange {
+	fields := map[string]bool{}
+
+	for field := range before {
+		fields[field] = true
+	}
+
+	for field := range after {
+		fields[field] = true
+	}
+
+	// The version changes on every update
+	delete(fields, "_id")
+	delete(fields, VersionField)
+
+	var diff []types.FieldChange
+
+	for field := range fields {
+		if !equal(before[field], after[field]) {
+			diff = append(diff, types.FieldChange{
+				Field:  field,
+				Before: before[field],
+				After:  after[field],
+			})
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Field < diff[j].Field
+	})
+
+	return diff
+}
+
+// Reading
+
+// ReadHistory returns the history entries selected by the query filter, from
+// oldest to newest.
+func ReadHistory(ctx context.Context, store Store, filter bson.D) ([]types.HistoryEntry, error) {
+	opts := &ReadOptions{
+		Sort: bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}},
+	}
+
+	documents, err := store.ReadAll(ctx, HistoryCollection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]types.HistoryEntry, 0, len(documents))
+
+	for _, document := range documents {
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+
+		var entry types.HistoryEntry
+		if err := bson.Unmarshal(raw, &entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ReadAllAt returns the documents of collection as they were at the given
+// time, reconstructed from the history, or only those of ids unless nil.
+// Documents that were in the trash at that time are not returned.
+//
+// Only the changes made after at are read: the state of a document at that
+// time is the state before its first later change, or its current state if
+// it has not changed since, provided its ObjectID was generated before at.
+func ReadAllAt(ctx context.Context, store Store, collection string, at time.Time, ids []primitive.ObjectID) ([]*bson.M, error) {
+	filter := bson.D{
+		{Key: "collection", Value: collection},
+		{Key: "time", Value: bson.D{{Key: "$gt", Value: at}}},
+	}
+
+	var current bson.D
+
+	if ids != nil {
+		in := bson.A{}
+		for _, id := range ids {
+			in = append(in, id)
+		}
+
+		filter = append(filter, bson.E{Key: "object", Value: bson.D{{Key: "$in", Value: in}}})
+		current = bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: in}}}}
+	}
+
+	entries, err := ReadHistory(ctx, store, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	states := map[primitive.ObjectID]bson.M{}
+
+	for _, entry := range entries {
+		// The first change after at starts from the state at that time
+		if _, ok := states[entry.Object]; !ok {
+			states[entry.Object] = entry.Before
+		}
+	}
+
+	documents, err := store.ReadAll(ctx, collection, current, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, document := range documents {
+		id, ok := (*document)["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+
+		if _, ok := states[id]; !ok && !id.Timestamp().After(at) {
+			states[id] = *document
+		}
+	}
+
+	results := []*bson.M{}
+
+	for _, state := range states {
+		// Not created yet, or deleted
+		if state == nil || IsTrashed(state) {
+			continue
+		}
+
+		state := state
+		results = append(results, &state)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return compareForSort((*results[i])["_id"], (*results[j])["_id"]) < 0
+	})
+
+	return results, nil
+}
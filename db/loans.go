@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LoanCollection holds the types.Loan of every object ever lent.
+const LoanCollection = "loans"
+
+// ActiveLoanCollection holds, with the ObjectID of each object that is lent,
+// the ObjectID of its active types.Loan, so that an object is never lent
+// twice at once. Active loans are kept out of the objects, whose version and
+// history they would change.
+const ActiveLoanCollection = "active_loans"
+
+// ActiveLoan returns the ObjectID of the active loan of the object id, and
+// whether it is lent.
+func ActiveLoan(ctx context.Context, store Store, id primitive.ObjectID) (primitive.ObjectID, bool, error) {
+	active, err := store.ReadFromID(ctx, ActiveLoanCollection, id)
+	if err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, false, nil
+	}
+	if err != nil {
+		return primitive.NilObjectID, false, err
+	}
+
+	loan, ok := active["loan"].(primitive.ObjectID)
+	return loan, ok, nil
+}
+
+// Lend marks the object id as lent by loan, unless it is already lent, and
+// reports whether it did.
+func Lend(ctx context.Context, store Store, id primitive.ObjectID, loan primitive.ObjectID) (bool, error) {
+	_, err := store.InsertMany(ctx, ActiveLoanCollection, []interface{}{bson.D{{Key: "_id", Value: id}, {Key: "loan", Value: loan}}})
+	if err == nil {
+		return true, nil
+	}
+
+	if _, readErr := store.ReadFromID(ctx, ActiveLoanCollection, id); readErr == nil {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Unlend marks the object id as no longer lent by loan, unless it is not, and
+// reports whether it did.
+func Unlend(ctx context.Context, store Store, id primitive.ObjectID, loan primitive.ObjectID) (bool, error) {
+	result, err := store.DeleteMany(ctx, ActiveLoanCollection, bson.D{{Key: "_id", Value: id}, {Key: "loan", Value: loan}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// RecordLoan records loan, checked out now by the actor of ctx.
+func RecordLoan(ctx context.Context, store Store, loan types.Loan) (*types.Loan, error) {
+	loan.CheckedOutAt = time.Now().UTC()
+	loan.CheckedOutBy = ActorFrom(ctx).Name
+
+	if _, err := store.InsertMany(ctx, LoanCollection, []interface{}{loan}); err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// ReturnLoan records that the loan id was checked in now by the actor of ctx,
+// with note. Loans already returned are left untouched.
+func ReturnLoan(ctx context.Context, store Store, id primitive.ObjectID, note string) error {
+	returned := bson.D{
+		{Key: "returned_at", Value: time.Now().UTC()},
+		{Key: "returned_by", Value: ActorFrom(ctx).Name},
+	}
+
+	if note != "" {
+		returned = append(returned, bson.E{Key: "return_note", Value: note})
+	}
+
+	query := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "returned_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+
+	_, err := store.UpdateMany(ctx, LoanCollection, query, bson.D{{Key: "$set", Value: returned}})
+	return err
+}
+
+// ReadLoans returns the loans selected by filter, sorted by sort.
+func ReadLoans(ctx context.Context, store Store, filter bson.D, sort bson.D) ([]types.Loan, error) {
+	documents, err := store.ReadAll(ctx, LoanCollection, filter, &ReadOptions{Sort: sort})
+	if err != nil {
+		return nil, err
+	}
+
+	loans := make([]types.Loan, 0, len(documents))
+
+	for _, document := range documents {
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+
+		var loan types.Loan
+		if err := bson.Unmarshal(raw, &loan); err != nil {
+			return nil, err
+		}
+
+		loans = append(loans, loan)
+	}
+
+	return loans, nil
+}
@@ -0,0 +1,459 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// This file implements the subset of the mongodb query language needed by
+// the stores that do not run on mongodb.
+//
+// Supported are implicit equality, the logical operators $and, $or and $nor,
+// and the field operators $eq, $ne, $in, $nin, $exists, $regex (with
+// $options), $gt, $gte, $lt and $lte. Field names may be dotted paths into
+// embedded documents.
+
+// normalizeQuery returns query with every embedded document as a bson.D and
+// every array as a bson.A, as expected by match.
+func normalizeQuery(query bson.D) (bson.D, error) {
+	if query == nil {
+		return bson.D{}, nil
+	}
+
+	raw, err := bson.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized bson.D
+	if err := bson.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// match reports whether document is selected by the normalized query.
+func match(document bson.M, query bson.D) (bool, error) {
+	for _, element := range query {
+		ok, err := matchElement(document, element)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchElement(document bson.M, element bson.E) (bool, error) {
+	switch element.Key {
+	case "$and", "$or", "$nor":
+		clauses, ok := element.Value.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("%s must be an array", element.Key)
+		}
+
+		for _, clause := range clauses {
+			query, ok := clause.(bson.D)
+			if !ok {
+				return false, fmt.Errorf("%s must be an array of documents", element.Key)
+			}
+
+			matched, err := match(document, query)
+			if err != nil {
+				return false, err
+			}
+
+			switch {
+			case element.Key == "$and" && !matched:
+				return false, nil
+			case element.Key == "$or" && matched:
+				return true, nil
+			case element.Key == "$nor" && matched:
+				return false, nil
+			}
+		}
+
+		return element.Key != "$or", nil
+	}
+
+	if strings.HasPrefix(element.Key, "$") {
+		return false, fmt.Errorf("Unsupported query operator '%s'", element.Key)
+	}
+
+	value, exists := lookupPath(document, element.Key)
+
+	switch operand := element.Value.(type) {
+	case bson.D:
+		if len(operand) > 0 && strings.HasPrefix(operand[0].Key, "$") {
+			return matchOperators(value, exists, operand)
+		}
+	case primitive.Regex:
+		return matchRegex(value, operand.Pattern, operand.Options)
+	}
+
+	return matchEqual(value, exists, element.Value), nil
+}
+
+func matchOperators(value interface{}, exists bool, operators bson.D) (bool, error) {
+	for _, operator := range operators {
+		var (
+			matched bool
+			err     error
+		)
+
+		switch operator.Key {
+		case "$eq":
+			matched = matchEqual(value, exists, operator.Value)
+		case "$ne":
+			matched = !matchEqual(value, exists, operator.Value)
+		case "$in", "$nin":
+			candidates, ok := operator.Value.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("%s must be an array", operator.Key)
+			}
+
+			for _, candidate := range candidates {
+				if matchEqual(value, exists, candidate) {
+					matched = true
+					break
+				}
+			}
+
+			if operator.Key == "$nin" {
+				matched = !matched
+			}
+		case "$exists":
+			matched = exists == truthy(operator.Value)
+		case "$regex":
+			var options string
+			if o, ok := lookup(operators, "$options").(string); ok {
+				options = o
+			}
+
+			switch pattern := operator.Value.(type) {
+			case string:
+				matched, err = matchRegex(value, pattern, options)
+			case primitive.Regex:
+				matched, err = matchRegex(value, pattern.Pattern, pattern.Options+options)
+			default:
+				return false, fmt.Errorf("$regex must be a string")
+			}
+		case "$options":
+			// Consumed by $regex
+			matched = true
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = matchAny(value, func(v interface{}) bool {
+				order, ok := compareValues(v, operator.Value)
+				if !ok {
+					return false
+				}
+
+				switch operator.Key {
+				case "$gt":
+					return order > 0
+				case "$gte":
+					return order >= 0
+				case "$lt":
+					return order < 0
+				default:
+					return order <= 0
+				}
+			})
+		default:
+			return false, fmt.Errorf("Unsupported query operator '%s'", operator.Key)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchEqual reports whether value equals operand, or contains it when value
+// is an array. A nil operand matches missing fields.
+func matchEqual(value interface{}, exists bool, operand interface{}) bool {
+	if operand == nil {
+		return !exists || value == nil
+	}
+
+	if !exists {
+		return false
+	}
+
+	if equal(value, operand) {
+		return true
+	}
+
+	if _, ok := operand.(bson.A); ok {
+		return false
+	}
+
+	if array, ok := value.(bson.A); ok {
+		for _, element := range array {
+			if equal(element, operand) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchAny reports whether fn is true for value, or for any of its elements
+// when value is an array.
+func matchAny(value interface{}, fn func(interface{}) bool) bool {
+	if array, ok := value.(bson.A); ok {
+		for _, element := range array {
+			if fn(element) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return fn(value)
+}
+
+func matchRegex(value interface{}, pattern, options string) (bool, error) {
+	var flags string
+	for _, option := range options {
+		if strings.ContainsRune("ims", option) && !strings.ContainsRune(flags, option) {
+			flags += string(option)
+		}
+	}
+
+	if flags != "" {
+		pattern = fmt.Sprintf("(?%s)%s", flags, pattern)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return matchAny(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	}), nil
+}
+
+// lookupPath returns the value at the dotted path in document.
+func lookupPath(document bson.M, path string) (interface{}, bool) {
+	var current interface{} = document
+
+	for _, key := range strings.Split(path, ".") {
+		switch d := current.(type) {
+		case bson.M:
+			value, ok := d[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case bson.D:
+			found := false
+			for _, element := range d {
+				if element.Key == key {
+					current = element.Value
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// equal reports whether two bson values are equal, regardless of their
+// numeric types.
+func equal(a, b interface{}) bool {
+	if order, ok := compareValues(a, b); ok {
+		return order == 0
+	}
+
+	arrayA, okA := a.(bson.A)
+	arrayB, okB := b.(bson.A)
+	if okA && okB {
+		if len(arrayA) != len(arrayB) {
+			return false
+		}
+
+		for i := range arrayA {
+			if !equal(arrayA[i], arrayB[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues orders two bson values of comparable types. The boolean is
+// false when the types cannot be compared.
+func compareValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, a == nil && b == nil
+	}
+
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	if x, ok := toTime(a); ok {
+		y, ok := toTime(b)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case x.Before(y):
+			return -1, true
+		case x.After(y):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	case primitive.ObjectID:
+		y, ok := b.(primitive.ObjectID)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(x[:], y[:]), true
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		}
+		return 1, true
+	}
+
+	return 0, false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v.Time(), true
+	case time.Time:
+		return v, true
+	}
+
+	return time.Time{}, false
+}
+
+func truthy(value interface{}) bool {
+	if b, ok := value.(bool); ok {
+		return b
+	}
+
+	if f, ok := toFloat(value); ok {
+		return f != 0
+	}
+
+	return value != nil
+}
+
+// compareForSort orders any two bson values, first by type like mongodb
+// does, then by value.
+func compareForSort(a, b interface{}) int {
+	if order, ok := compareValues(a, b); ok {
+		return order
+	}
+
+	rankA, rankB := typeRank(a), typeRank(b)
+	switch {
+	case rankA < rankB:
+		return -1
+	case rankA > rankB:
+		return 1
+	}
+
+	return 0
+}
+
+func typeRank(value interface{}) int {
+	if value == nil {
+		return 0
+	}
+
+	if _, ok := toFloat(value); ok {
+		return 1
+	}
+
+	if _, ok := toTime(value); ok {
+		return 7
+	}
+
+	switch value.(type) {
+	case string:
+		return 2
+	case bson.M, bson.D:
+		return 3
+	case bson.A:
+		return 4
+	case primitive.ObjectID:
+		return 5
+	case bool:
+		return 6
+	}
+
+	return 8
+}
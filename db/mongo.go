@@ -0,0 +1,607 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is a Store backed by a MongoDB server.
+//
+// A single client is shared by every operation, and its connection pool is
+// reused across requests.
+type MongoStore struct {
+	client *mongo.Client
+
+	// unique are the unique keys indexed by EnsureUnique, replaced as a whole
+	// when they change
+	unique   uniqueKeys
+	uniqueMu sync.RWMutex
+}
+
+// NewMongoStore returns a MongoStore connected to the server at uri.
+func NewMongoStore(ctx context.Context, uri string) (*MongoStore, error) {
+	// Use the SetServerAPIOptions() method to set the Stable API version to 1
+	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
+	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI)
+
+	// Create a new client and connect to the server
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoStore{client: client}, nil
+}
+
+// EnsureHistoryIndexes indexes the history by object and by time, within each
+// collection, as it is read by ReadHistory and ReadAllAt.
+func (s *MongoStore) EnsureHistoryIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "object", Value: 1}, {Key: "time", Value: 1}},
+			Options: options.Index().SetName("history_collection_object_time"),
+		},
+		{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "time", Value: 1}},
+			Options: options.Index().SetName("history_collection_time"),
+		},
+	}
+
+	_, err := s.collection(HistoryCollection).Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Close disconnects the client from the server.
+func (s *MongoStore) Close() error {
+	return s.client.Disconnect(context.Background())
+}
+
+func (s *MongoStore) collection(name string) *mongo.Collection {
+	return s.client.Database("haul").Collection(name)
+}
+
+func (s *MongoStore) Ping(ctx context.Context) (bson.M, error) {
+	var result bson.M
+
+	if err := s.client.Database("admin").RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Create
+
+func (s *MongoStore) CreateComponent(ctx context.Context, component types.Component) (*mongo.InsertOneResult, error) {
+	if component.Name == "" {
+		return nil, errors.New("component.Name cannot be empty")
+	}
+
+	object, err := newObject(component)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertOne(ctx, "components", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) CreateComponents(ctx context.Context, components types.Components) (*mongo.InsertManyResult, error) {
+	for _, component := range components.Components {
+		if component.Name == "" {
+			return nil, errors.New("component.Name cannot be empty")
+		}
+	}
+	data := make([]interface{}, len(components.Components))
+	for i := range components.Components {
+		data[i] = components.Components[i]
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertMany(ctx, "components", objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) CreateAssembly(ctx context.Context, assembly types.Assembly) (*mongo.InsertOneResult, error) {
+	if assembly.Name == "" {
+		return nil, errors.New("assembly.Name cannot be empty")
+	}
+
+	object, err := newObject(assembly)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertOne(ctx, "assemblies", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) CreateAssemblies(ctx context.Context, assemblies types.Assemblies) (*mongo.InsertManyResult, error) {
+	for _, assembly := range assemblies.Assemblies {
+		if assembly.Name == "" {
+			return nil, errors.New("assembly.Name cannot be empty")
+		}
+	}
+	data := make([]interface{}, len(assemblies.Assemblies))
+	for i := range assemblies.Assemblies {
+		data[i] = assemblies.Assemblies[i]
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertMany(ctx, "assemblies", objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) CreateKit(ctx context.Context, kit types.Kit) (*mongo.InsertOneResult, error) {
+	if kit.Name == "" {
+		return nil, errors.New("kit.Name cannot be empty")
+	}
+
+	object, err := newObject(kit)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertOne(ctx, "kits", object)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) CreateKits(ctx context.Context, kits types.Kits) (*mongo.InsertManyResult, error) {
+	for _, kit := range kits.Kits {
+		if kit.Name == "" {
+			return nil, errors.New("kit.Name cannot be empty")
+		}
+	}
+	data := make([]interface{}, len(kits.Kits))
+	for i := range kits.Kits {
+		data[i] = kits.Kits[i]
+	}
+
+	objects, err := newObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.insertMany(ctx, "kits", objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	if versionedCollections[collection] {
+		var err error
+		if documents, err = newObjects(documents); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.insertMany(ctx, collection, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// insertOne inserts document in collection, along with the values of its
+// unique tag keys.
+func (s *MongoStore) insertOne(ctx context.Context, collection string, document bson.D) (*mongo.InsertOneResult, error) {
+	document = s.withUniqueTags(collection, document)
+
+	result, err := s.collection(collection).InsertOne(ctx, document)
+	if err != nil {
+		return nil, s.duplicateError(ctx, collection, err, []bson.D{document})
+	}
+
+	return result, nil
+}
+
+// insertMany inserts documents in collection, along with the values of their
+// unique tag keys. No document is inserted if one holds a duplicate value.
+func (s *MongoStore) insertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	if len(s.keys()[collection]) == 0 {
+		return s.collection(collection).InsertMany(ctx, documents)
+	}
+
+	ds := make([]bson.D, len(documents))
+	ids := bson.A{}
+
+	for i, document := range documents {
+		d, err := toD(document)
+		if err != nil {
+			return nil, err
+		}
+
+		id, ok := lookup(d, "_id").(primitive.ObjectID)
+		if !ok {
+			id = primitive.NewObjectID()
+			d = append(bson.D{{Key: "_id", Value: id}}, d...)
+		}
+
+		ds[i] = s.withUniqueTags(collection, d)
+		documents[i] = ds[i]
+		ids = append(ids, id)
+	}
+
+	if err := s.keys().batchDuplicate(collection, ds); err != nil {
+		return nil, err
+	}
+
+	result, err := s.collection(collection).InsertMany(ctx, documents)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// The documents inserted before the duplicate are removed
+			if _, undoErr := s.collection(collection).DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}); undoErr != nil {
+				log.Printf("[error] Could not remove the documents inserted in %s before a duplicate: %s\n", collection, undoErr)
+			}
+		}
+
+		return nil, s.duplicateError(ctx, collection, err, ds)
+	}
+
+	return result, nil
+}
+
+// Read
+
+func (s *MongoStore) ReadFromID(ctx context.Context, collection string, id primitive.ObjectID) (bson.M, error) {
+	var result bson.M
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	err := s.collection(collection).FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		return result, err
+	}
+
+	delete(result, UniqueTagsField)
+
+	return result, nil
+}
+
+func (s *MongoStore) ReadAll(ctx context.Context, collection string, filter bson.D, opts *ReadOptions) ([]*bson.M, error) {
+	var components []*bson.M
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	findOptions := options.Find()
+
+	if opts != nil {
+		if len(opts.Sort) > 0 {
+			findOptions.SetSort(opts.Sort)
+		}
+
+		if opts.Limit > 0 {
+			findOptions.SetLimit(opts.Limit)
+		}
+
+		if len(opts.Projection) > 0 {
+			projection := bson.D{}
+			for _, field := range opts.Projection {
+				projection = append(projection, bson.E{Key: field, Value: 1})
+			}
+			findOptions.SetProjection(projection)
+		}
+	}
+
+	cursor, err := s.collection(collection).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var component bson.M
+		err := cursor.Decode(&component)
+		if err != nil {
+			return nil, err
+		}
+		delete(component, UniqueTagsField)
+		components = append(components, &component)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+func (s *MongoStore) Count(ctx context.Context, collection string, filter bson.D) (int64, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	return s.collection(collection).CountDocuments(ctx, filter)
+}
+
+// Delete
+
+func (s *MongoStore) DeleteFromID(ctx context.Context, collection string, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	result, err := s.collection(collection).DeleteOne(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) DeleteMany(ctx context.Context, collection string, filter bson.D) (*mongo.DeleteResult, error) {
+	result, err := s.collection(collection).DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Update
+
+func (s *MongoStore) UpdateFromID(ctx context.Context, collection string, id primitive.ObjectID, data bson.D) (*mongo.UpdateResult, error) {
+	// Empty name validation
+
+	if err := validateUpdate(data); err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	result, err := s.collection(collection).UpdateOne(ctx, filter, s.withUniqueTagsUpdate(collection, withVersionIncrement(collection, data)))
+	if err != nil {
+		return nil, s.updateDuplicateError(ctx, collection, err, filter, data)
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) UpdateMany(ctx context.Context, collection string, filter bson.D, data bson.D) (*mongo.UpdateResult, error) {
+	// Empty name validation
+
+	if err := validateUpdate(data); err != nil {
+		return nil, err
+	}
+
+	result, err := s.collection(collection).UpdateMany(ctx, filter, s.withUniqueTagsUpdate(collection, withVersionIncrement(collection, data)))
+	if err != nil {
+		return nil, s.updateDuplicateError(ctx, collection, err, filter, data)
+	}
+
+	return result, nil
+}
+
+// Constraints
+
+func (s *MongoStore) EnsureUnique(ctx context.Context, collection, key string) error {
+	unique := s.keys().add(collection, key)
+	path := uniquePath(key)
+
+	if strings.HasPrefix(key, types.TagValuesPrefix) {
+		// The documents written before the key was unique do not hold the
+		// values of its tags yet
+		documents, err := s.ReadAll(ctx, collection, nil, &ReadOptions{Projection: []string{"tags"}})
+		if err != nil {
+			return err
+		}
+
+		for _, document := range documents {
+			d, err := toD(*document)
+			if err != nil {
+				return err
+			}
+
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: UniqueTagsField, Value: unique.tagValues(collection, d)}}}}
+
+			if _, err := s.collection(collection).UpdateOne(ctx, bson.D{{Key: "_id", Value: (*document)["_id"]}}, update); err != nil {
+				return err
+			}
+		}
+	}
+
+	documents, err := s.ReadAll(ctx, collection, NotTrashed(), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := existingDuplicate(collection, key, documents); err != nil {
+		return err
+	}
+
+	// Objects in the trash keep their values, each with its time of deletion
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: path, Value: 1}, {Key: TrashedField, Value: 1}},
+		Options: options.Index().
+			SetName(uniqueIndex(key)).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{{Key: path, Value: bson.D{{Key: "$exists", Value: true}}}}),
+	}
+
+	if _, err := s.collection(collection).Indexes().CreateOne(ctx, index); err != nil {
+		// Documents written since they were read may share a value
+		if mongo.IsDuplicateKeyError(err) {
+			if documents, readErr := s.ReadAll(ctx, collection, NotTrashed(), nil); readErr == nil {
+				if duplicate := existingDuplicate(collection, key, documents); duplicate != nil {
+					return duplicate
+				}
+			}
+		}
+
+		return err
+	}
+
+	s.uniqueMu.Lock()
+	s.unique = s.unique.add(collection, key)
+	s.uniqueMu.Unlock()
+
+	return nil
+}
+
+func (s *MongoStore) DropUnique(ctx context.Context, collection, key string) error {
+	s.uniqueMu.Lock()
+	s.unique = s.unique.remove(collection, key)
+	s.uniqueMu.Unlock()
+
+	_, err := s.collection(collection).Indexes().DropOne(ctx, uniqueIndex(key))
+
+	// The index was never created
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == indexNotFoundCode {
+		return nil
+	}
+
+	return err
+}
+
+// indexNotFoundCode is the code of the error dropping an unknown index.
+const indexNotFoundCode = 27
+
+// uniqueIndex returns the name of the index of the unique key.
+func uniqueIndex(key string) string {
+	return "unique_" + strings.ReplaceAll(key, ".", "_")
+}
+
+// keys returns the unique keys indexed by EnsureUnique.
+func (s *MongoStore) keys() uniqueKeys {
+	s.uniqueMu.RLock()
+	defer s.uniqueMu.RUnlock()
+
+	return s.unique
+}
+
+// withUniqueTags returns document holding the values of the unique tag keys
+// of collection, if it has any.
+func (s *MongoStore) withUniqueTags(collection string, document bson.D) bson.D {
+	if !s.keys().hasTagKeys(collection) {
+		return document
+	}
+
+	return set(document, UniqueTagsField, s.keys().tagValues(collection, document))
+}
+
+// withUniqueTagsUpdate returns the update data, also setting, or unsetting,
+// the values of the unique tag keys of collection if it sets, or unsets, the
+// tags.
+func (s *MongoStore) withUniqueTagsUpdate(collection string, data bson.D) bson.D {
+	if !s.keys().hasTagKeys(collection) {
+		return data
+	}
+
+	update := make(bson.D, 0, len(data))
+
+	for _, element := range data {
+		fields, err := toD(element.Value)
+		if err == nil && lookup(fields, "tags") != nil {
+			switch element.Key {
+			case "$set":
+				fields = set(append(bson.D{}, fields...), UniqueTagsField, s.keys().tagValues(collection, fields))
+				element = bson.E{Key: element.Key, Value: fields}
+			case "$unset":
+				fields = set(append(bson.D{}, fields...), UniqueTagsField, "")
+				element = bson.E{Key: element.Key, Value: fields}
+			}
+		}
+
+		update = append(update, element)
+	}
+
+	return update
+}
+
+// duplicateError returns a *DuplicateError in place of err if it is the
+// duplicate key error of writing documents to collection.
+func (s *MongoStore) duplicateError(ctx context.Context, collection string, err error, documents []bson.D) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	find := func(query bson.D) (bson.M, error) {
+		var found bson.M
+
+		err := s.collection(collection).FindOne(ctx, query).Decode(&found)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+
+		return found, err
+	}
+
+	for _, document := range documents {
+		if duplicate := s.keys().duplicate(collection, document, find); duplicate != nil {
+			return duplicate
+		}
+	}
+
+	return err
+}
+
+// updateDuplicateError is duplicateError for the documents of collection
+// selected by the query filter, as they would be after the update data.
+func (s *MongoStore) updateDuplicateError(ctx context.Context, collection string, err error, filter, data bson.D) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	documents, readErr := s.ReadAll(ctx, collection, filter, nil)
+	if readErr != nil {
+		return err
+	}
+
+	updated := make([]bson.D, 0, len(documents))
+
+	for _, document := range documents {
+		d, convErr := toD(*document)
+		if convErr != nil {
+			return err
+		}
+
+		if d, convErr = applyUpdate(d, data); convErr != nil {
+			return err
+		}
+
+		updated = append(updated, d)
+	}
+
+	return s.duplicateError(ctx, collection, err, updated)
+}
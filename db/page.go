@@ -0,0 +1,151 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ReadOptions controls the order, size and fields of the documents returned
+// by Store#ReadAll.
+type ReadOptions struct {
+	// Sort orders documents by its fields, 1 for ascending and -1 for
+	// descending order. See SortOrder.
+	Sort bson.D
+
+	// Limit is the maximum number of documents returned, 0 for no limit.
+	Limit int64
+
+	// Projection lists the only fields returned, or every field when empty.
+	// "_id" is always returned.
+	Projection []string
+}
+
+// SortOrder parses a comma-separated list of fields, each optionally
+// prefixed by '-' for descending order, into a sort document.
+//
+// "_id" is appended as the last sort field if absent, so that the order is
+// total, as needed by cursors.
+func SortOrder(sort string) (bson.D, error) {
+	var order bson.D
+
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		direction := 1
+		if strings.HasPrefix(field, "-") {
+			direction = -1
+			field = strings.TrimPrefix(field, "-")
+		}
+
+		if field == "" || lookup(order, field) != nil {
+			return nil, fmt.Errorf("Invalid sort '%s'", sort)
+		}
+
+		order = append(order, bson.E{Key: field, Value: direction})
+	}
+
+	if lookup(order, "_id") == nil {
+		order = append(order, bson.E{Key: "_id", Value: 1})
+	}
+
+	return order, nil
+}
+
+// cursor is the content of an encoded cursor: the sort order of the page and
+// the sort values of its last document.
+type cursor struct {
+	Order  bson.D `bson:"o"`
+	Values bson.A `bson:"v"`
+}
+
+// Cursor returns the cursor pointing after document in the given sort order.
+func Cursor(order bson.D, document bson.M) (string, error) {
+	c := cursor{Order: order}
+
+	for _, field := range order {
+		value, _ := lookupPath(document, field.Key)
+		c.Values = append(c.Values, value)
+	}
+
+	raw, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// CursorQuery returns the query selecting the documents that come after the
+// encoded cursor in the given sort order.
+func CursorQuery(order bson.D, encoded string) (bson.D, error) {
+	errInvalid := errors.New("Invalid cursor, it must be the 'next' value of a previous page with the same sort")
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	var c cursor
+	if err := bson.Unmarshal(raw, &c); err != nil {
+		return nil, errInvalid
+	}
+
+	if len(c.Order) != len(order) || len(c.Values) != len(order) {
+		return nil, errInvalid
+	}
+
+	for i := range order {
+		if c.Order[i].Key != order[i].Key || !equal(c.Order[i].Value, order[i].Value) {
+			return nil, errInvalid
+		}
+	}
+
+	// (a > x) OR (a == x AND b > y) OR ...
+	var clauses bson.A
+
+	for i, field := range order {
+		clause := bson.D{}
+
+		for j := 0; j < i; j++ {
+			clause = append(clause, bson.E{Key: order[j].Key, Value: c.Values[j]})
+		}
+
+		operator := "$gt"
+		if d, _ := toFloat(field.Value); d < 0 {
+			operator = "$lt"
+		}
+
+		clause = append(clause, bson.E{Key: field.Key, Value: bson.D{{Key: operator, Value: c.Values[i]}}})
+
+		clauses = append(clauses, clause)
+	}
+
+	return bson.D{{Key: "$or", Value: clauses}}, nil
+}
+
+// And returns a query selecting the documents matched by every query.
+func And(queries ...bson.D) bson.D {
+	var clauses bson.A
+
+	for _, query := range queries {
+		if len(query) > 0 {
+			clauses = append(clauses, query)
+		}
+	}
+
+	switch len(clauses) {
+	case 0:
+		return bson.D{}
+	case 1:
+		return clauses[0].(bson.D)
+	}
+
+	return bson.D{{Key: "$and", Value: clauses}}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReservationCollection holds the types.Reservation of every kit.
+const ReservationCollection = "reservations"
+
+// ReservationCounterCollection holds, with the ObjectID of each kit ever
+// reserved, the number of its reservations, so that two reservations of the
+// same kit are never checked against its bookings at once, see
+// CountReservation. Counters are kept out of the kits, whose version and
+// history they would change.
+const ReservationCounterCollection = "reservation_counters"
+
+// Reserved returns the number of reservations ever made of the kit id.
+func Reserved(ctx context.Context, store Store, kit primitive.ObjectID) (int64, error) {
+	counter, err := store.ReadFromID(ctx, ReservationCounterCollection, kit)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	reserved, _ := toFloat(counter["reserved"])
+	return int64(reserved), nil
+}
+
+// CountReservation counts a reservation of the kit id, only if the kit still
+// counts reserved reservations, as read before its bookings were checked. It
+// reports false if another reservation was counted in the meantime.
+func CountReservation(ctx context.Context, store Store, kit primitive.ObjectID, reserved int64) (bool, error) {
+	if reserved == 0 {
+		// The first reservation creates the counter, once
+		_, err := store.InsertMany(ctx, ReservationCounterCollection, []interface{}{bson.D{{Key: "_id", Value: kit}, {Key: "reserved", Value: int64(1)}}})
+		if err == nil {
+			return true, nil
+		}
+
+		if _, readErr := store.ReadFromID(ctx, ReservationCounterCollection, kit); readErr == nil {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	query := bson.D{{Key: "_id", Value: kit}, {Key: "reserved", Value: reserved}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "reserved", Value: int64(1)}}}}
+
+	result, err := store.UpdateMany(ctx, ReservationCounterCollection, query, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// Overlapping returns the query selecting the reservations that book their
+// kit at some time between start and end, see types.Reservation.Overlaps.
+func Overlapping(start, end time.Time) bson.D {
+	return bson.D{
+		{Key: "start", Value: bson.D{{Key: "$lt", Value: end}}},
+		{Key: "end", Value: bson.D{{Key: "$gt", Value: start}}},
+	}
+}
+
+// RecordReservation records reservation, made now by the actor of ctx.
+func RecordReservation(ctx context.Context, store Store, reservation types.Reservation) (*types.Reservation, error) {
+	reservation.CreatedAt = time.Now().UTC()
+	reservation.CreatedBy = ActorFrom(ctx).Name
+
+	if _, err := store.InsertMany(ctx, ReservationCollection, []interface{}{reservation}); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// ReadReservations returns the reservations selected by filter, from the
+// earliest start.
+func ReadReservations(ctx context.Context, store Store, filter bson.D) ([]types.Reservation, error) {
+	opts := &ReadOptions{
+		Sort: bson.D{{Key: "start", Value: 1}, {Key: "_id", Value: 1}},
+	}
+
+	documents, err := store.ReadAll(ctx, ReservationCollection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]types.Reservation, 0, len(documents))
+
+	for _, document := range documents {
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+
+		var reservation types.Reservation
+		if err := bson.Unmarshal(raw, &reservation); err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SchemaCollection holds the types.Schema of the kinds with custom fields.
+const SchemaCollection = "schemas"
+
+// ReadSchema returns the schema of kind, without fields if it has none.
+func ReadSchema(ctx context.Context, store Store, kind string) (*types.Schema, error) {
+	schema := types.Schema{Kind: kind, Fields: []types.Field{}}
+
+	documents, err := store.ReadAll(ctx, SchemaCollection, bson.D{{Key: "kind", Value: kind}}, nil)
+	if err != nil || len(documents) == 0 {
+		return &schema, err
+	}
+
+	raw, err := bson.Marshal(documents[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bson.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+
+	if schema.Fields == nil {
+		schema.Fields = []types.Field{}
+	}
+
+	return &schema, nil
+}
+
+// WriteSchema replaces the schema of its kind.
+func WriteSchema(ctx context.Context, store Store, schema types.Schema) error {
+	query := bson.D{{Key: "kind", Value: schema.Kind}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "fields", Value: schema.Fields}}}}
+
+	result, err := store.UpdateMany(ctx, SchemaCollection, query, update)
+	if err != nil || result.MatchedCount > 0 {
+		return err
+	}
+
+	_, err = store.InsertMany(ctx, SchemaCollection, []interface{}{schema})
+	return err
+}
+
+// uniqueFields returns the unique keys of the unique custom fields of schema,
+// see Store.EnsureUnique.
+func uniqueFields(schema *types.Schema) []string {
+	keys := []string{}
+
+	if schema != nil {
+		for _, field := range schema.Fields {
+			if field.Unique {
+				keys = append(keys, types.FieldValuesPrefix+field.Name)
+			}
+		}
+	}
+
+	return keys
+}
+
+// EnsureUniqueFields makes the values of the unique custom fields of schema
+// unique among the objects of its kind in store. If one cannot be, those that
+// were not unique in previous, which may be nil, are shared again.
+func EnsureUniqueFields(ctx context.Context, store Store, previous, schema *types.Schema) error {
+	collection, ok := uniqueCollections[schema.Kind]
+	if !ok {
+		return fmt.Errorf("Invalid kind '%s'", schema.Kind)
+	}
+
+	unique := map[string]bool{}
+
+	for _, key := range uniqueFields(previous) {
+		unique[key] = true
+	}
+
+	ensured := []string{}
+
+	for _, key := range uniqueFields(schema) {
+		if err := store.EnsureUnique(ctx, collection, key); err != nil {
+			for _, key := range ensured {
+				if !unique[key] {
+					store.DropUnique(ctx, collection, key)
+				}
+			}
+
+			return err
+		}
+
+		ensured = append(ensured, key)
+	}
+
+	return nil
+}
+
+// DropUniqueFields lets the objects of the kind of schema share the values of
+// the unique custom fields of previous that schema no longer has.
+func DropUniqueFields(ctx context.Context, store Store, previous, schema *types.Schema) error {
+	collection, ok := uniqueCollections[schema.Kind]
+	if !ok {
+		return fmt.Errorf("Invalid kind '%s'", schema.Kind)
+	}
+
+	unique := map[string]bool{}
+
+	for _, key := range uniqueFields(schema) {
+		unique[key] = true
+	}
+
+	for _, key := range uniqueFields(previous) {
+		if unique[key] {
+			continue
+		}
+
+		if err := store.DropUnique(ctx, collection, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockCollection holds the types.StockMovement of every component.
+const StockCollection = "stock"
+
+// RecordStockMovement records the change of the quantity of component by
+// delta, to quantity, made by the actor of ctx.
+func RecordStockMovement(ctx context.Context, store Store, component primitive.ObjectID, delta, quantity float64, reason string) (*types.StockMovement, error) {
+	actor := ActorFrom(ctx)
+
+	movement := types.StockMovement{
+		ID:        primitive.NewObjectID(),
+		Component: component,
+		Delta:     delta,
+		Quantity:  quantity,
+		Reason:    reason,
+		Actor:     actor.Name,
+		RemoteIP:  actor.RemoteIP,
+		Time:      time.Now().UTC(),
+	}
+
+	if _, err := store.InsertMany(ctx, StockCollection, []interface{}{movement}); err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+// ReadStockMovements returns the movements of the stock of component, from
+// oldest to newest.
+func ReadStockMovements(ctx context.Context, store Store, component primitive.ObjectID) ([]types.StockMovement, error) {
+	opts := &ReadOptions{
+		Sort: bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}},
+	}
+
+	documents, err := store.ReadAll(ctx, StockCollection, bson.D{{Key: "component", Value: component}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]types.StockMovement, 0, len(documents))
+
+	for _, document := range documents {
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+
+		var movement types.StockMovement
+		if err := bson.Unmarshal(raw, &movement); err != nil {
+			return nil, err
+		}
+
+		movements = append(movements, movement)
+	}
+
+	return movements, nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TrashedField is set to the time of deletion on the objects in the trash.
+//
+// Deleted objects stay in their collection until they are purged, so that
+// they can be restored.
+const TrashedField = "deleted_at"
+
+// TrashCollections are the collections whose deleted objects go to the trash.
+var TrashCollections = []string{"components", "assemblies", "kits", "locations"}
+
+// NotTrashed returns the query selecting the documents that are not in the
+// trash.
+func NotTrashed() bson.D {
+	return bson.D{{Key: TrashedField, Value: bson.D{{Key: "$exists", Value: false}}}}
+}
+
+// Trashed returns the query selecting the documents that are in the trash.
+func Trashed() bson.D {
+	return bson.D{{Key: TrashedField, Value: bson.D{{Key: "$exists", Value: true}}}}
+}
+
+// IsTrashed reports whether document is in the trash.
+func IsTrashed(document bson.M) bool {
+	_, ok := document[TrashedField]
+	return ok
+}
+
+// Trash moves the object id of collection to the trash, with at as its time
+// of deletion. Objects already in the trash are left untouched.
+func Trash(ctx context.Context, store Store, collection string, id primitive.ObjectID, at time.Time) (*mongo.UpdateResult, error) {
+	filter := And(bson.D{{Key: "_id", Value: id}}, NotTrashed())
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: TrashedField, Value: at}}}}
+
+	return store.UpdateMany(ctx, collection, filter, update)
+}
+
+// Restore takes the object id of collection out of the trash, setting the
+// fields of set and unsetting those of unset in the same update.
+func Restore(ctx context.Context, store Store, collection string, id primitive.ObjectID, set, unset bson.D) (*mongo.UpdateResult, error) {
+	filter := And(bson.D{{Key: "_id", Value: id}}, Trashed())
+
+	update := bson.D{{Key: "$unset", Value: append(bson.D{{Key: TrashedField, Value: ""}}, unset...)}}
+	if len(set) > 0 {
+		update = append(update, bson.E{Key: "$set", Value: set})
+	}
+
+	return store.UpdateMany(ctx, collection, filter, update)
+}
+
+// PurgeTrash permanently deletes the objects moved to the trash before the
+// given time, in every collection of TrashCollections. A zero time purges the
+// whole trash.
+func PurgeTrash(ctx context.Context, store Store, before time.Time) (*mongo.DeleteResult, error) {
+	total := &mongo.DeleteResult{}
+
+	filter := Trashed()
+	if !before.IsZero() {
+		filter = bson.D{{Key: TrashedField, Value: bson.D{{Key: "$lt", Value: before}}}}
+	}
+
+	for _, collection := range TrashCollections {
+		result, err := store.DeleteMany(ctx, collection, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		total.DeletedCount += result.DeletedCount
+	}
+
+	return total, nil
+}
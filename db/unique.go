@@ -0,0 +1,290 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"codeberg.org/haulproject/haul/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UniqueTagsField holds, in the documents of a MongoStore, the values of
+// their unique tag keys by key, on which the unique indexes are built since
+// tags cannot be indexed by key.
+const UniqueTagsField = "unique_tags"
+
+// uniqueCollections are the collections of the kinds of objects a
+// types.UniqueRule may apply to.
+var uniqueCollections = map[string]string{
+	"component": "components",
+	"assembly":  "assemblies",
+	"kit":       "kits",
+	"location":  "locations",
+}
+
+// EnsureUnique enforces rule on the objects of its kind in store, see
+// Store.EnsureUnique.
+func EnsureUnique(ctx context.Context, store Store, rule types.UniqueRule) error {
+	collection, ok := uniqueCollections[rule.Kind]
+	if !ok {
+		return fmt.Errorf("Invalid kind '%s'", rule.Kind)
+	}
+
+	return store.EnsureUnique(ctx, collection, rule.Key)
+}
+
+// DuplicateError is returned by the writes that would give a document the
+// value of a unique key held by another document, see Store.EnsureUnique.
+type DuplicateError struct {
+	Collection string
+	Key        string // types.UniqueKeyName, "tag.<key>" or "field.<name>"
+	Value      interface{}
+
+	// ID is the document already holding the value, or the NilObjectID if
+	// several of the documents written at once hold it.
+	ID primitive.ObjectID
+}
+
+func (e *DuplicateError) Error() string {
+	if e.ID.IsZero() {
+		return fmt.Sprintf("The %s '%v' is given to several objects", e.Key, e.Value)
+	}
+
+	return fmt.Sprintf("The %s '%v' is already used by %s", e.Key, e.Value, e.ID.Hex())
+}
+
+// uniqueKeys are the unique keys of the documents of each collection.
+type uniqueKeys map[string][]string
+
+// add returns the unique keys with key added to collection, if it is not
+// already unique.
+func (u uniqueKeys) add(collection, key string) uniqueKeys {
+	for _, k := range u[collection] {
+		if k == key {
+			return u
+		}
+	}
+
+	added := uniqueKeys{}
+
+	for c, keys := range u {
+		added[c] = keys
+	}
+
+	added[collection] = append(append([]string{}, u[collection]...), key)
+
+	return added
+}
+
+// remove returns the unique keys without key in collection.
+func (u uniqueKeys) remove(collection, key string) uniqueKeys {
+	removed := uniqueKeys{}
+
+	for c, keys := range u {
+		removed[c] = keys
+	}
+
+	removed[collection] = []string{}
+
+	for _, k := range u[collection] {
+		if k != key {
+			removed[collection] = append(removed[collection], k)
+		}
+	}
+
+	return removed
+}
+
+// hasTagKeys reports whether collection has unique tag keys.
+func (u uniqueKeys) hasTagKeys(collection string) bool {
+	for _, key := range u[collection] {
+		if strings.HasPrefix(key, types.TagValuesPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagValues returns the values of the unique tag keys of collection held by
+// document, by key, as stored in UniqueTagsField. Keys without values are
+// left out.
+func (u uniqueKeys) tagValues(collection string, document bson.D) bson.D {
+	values := bson.D{}
+
+	for _, key := range u[collection] {
+		if !strings.HasPrefix(key, types.TagValuesPrefix) {
+			continue
+		}
+
+		if held := uniqueValues(key, document); len(held) > 0 {
+			values = append(values, bson.E{Key: strings.TrimPrefix(key, types.TagValuesPrefix), Value: held})
+		}
+	}
+
+	return values
+}
+
+// changed reports whether the values of the unique keys of collection
+// differ between before and after, or after was taken out of the trash.
+func (u uniqueKeys) changed(collection string, before, after bson.D) bool {
+	if lookup(before, TrashedField) != nil && lookup(after, TrashedField) == nil {
+		return true
+	}
+
+	for _, key := range u[collection] {
+		if !reflect.DeepEqual(uniqueValues(key, before), uniqueValues(key, after)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// duplicate returns a *DuplicateError if a document returned by find holds a
+// value of a unique key of document. find returns the first document
+// selected by its query, or nil. Documents in the trash hold no values.
+func (u uniqueKeys) duplicate(collection string, document bson.D, find func(query bson.D) (bson.M, error)) error {
+	if lookup(document, TrashedField) != nil {
+		return nil
+	}
+
+	id, _ := lookup(document, "_id").(primitive.ObjectID)
+
+	for _, key := range u[collection] {
+		for _, value := range uniqueValues(key, document) {
+			holder, err := find(duplicateQuery(key, value, id))
+			if err != nil {
+				return err
+			}
+
+			if holder != nil {
+				holderID, _ := holder["_id"].(primitive.ObjectID)
+				return &DuplicateError{Collection: collection, Key: key, Value: value, ID: holderID}
+			}
+		}
+	}
+
+	return nil
+}
+
+// batchDuplicate returns a *DuplicateError if several of documents, written
+// at once, hold the same value of a unique key.
+func (u uniqueKeys) batchDuplicate(collection string, documents []bson.D) error {
+	for _, key := range u[collection] {
+		held := map[string]bool{}
+
+		for _, document := range documents {
+			if lookup(document, TrashedField) != nil {
+				continue
+			}
+
+			for _, value := range uniqueValues(key, document) {
+				if held[valueKey(value)] {
+					return &DuplicateError{Collection: collection, Key: key, Value: value}
+				}
+
+				held[valueKey(value)] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// existingDuplicate returns a *DuplicateError if several of documents, read
+// from collection, hold the same value of key.
+func existingDuplicate(collection, key string, documents []*bson.M) error {
+	holders := map[string]primitive.ObjectID{}
+
+	for _, document := range documents {
+		d, err := toD(*document)
+		if err != nil {
+			return err
+		}
+
+		id, _ := (*document)["_id"].(primitive.ObjectID)
+
+		for _, value := range uniqueValues(key, d) {
+			if holder, ok := holders[valueKey(value)]; ok {
+				return &DuplicateError{Collection: collection, Key: key, Value: value, ID: holder}
+			}
+
+			holders[valueKey(value)] = id
+		}
+	}
+
+	return nil
+}
+
+// duplicateQuery returns the query selecting the documents other than id,
+// and not in the trash, holding value of the unique key.
+func duplicateQuery(key string, value interface{}, id primitive.ObjectID) bson.D {
+	held := bson.D{{Key: uniquePath(key), Value: value}}
+
+	if strings.HasPrefix(key, types.TagValuesPrefix) {
+		held = bson.D{{Key: "tags", Value: types.Tag(strings.TrimPrefix(key, types.TagValuesPrefix), fmt.Sprint(value))}}
+	}
+
+	return And(held, bson.D{{Key: "_id", Value: bson.D{{Key: "$ne", Value: id}}}}, NotTrashed())
+}
+
+// uniquePath returns the path of the values of the unique key in the
+// documents: "name", "fields.<name>", or "unique_tags.<key>" in those of a
+// MongoStore.
+func uniquePath(key string) string {
+	switch {
+	case strings.HasPrefix(key, types.TagValuesPrefix):
+		return UniqueTagsField + "." + strings.TrimPrefix(key, types.TagValuesPrefix)
+	case strings.HasPrefix(key, types.FieldValuesPrefix):
+		return "fields." + strings.TrimPrefix(key, types.FieldValuesPrefix)
+	}
+
+	return "name"
+}
+
+// uniqueValues returns the values of the unique key held by document, see
+// types.UniqueValues. A custom field holds a single value, of any type.
+func uniqueValues(key string, document bson.D) []interface{} {
+	values := []interface{}{}
+
+	if strings.HasPrefix(key, types.FieldValuesPrefix) {
+		if fields, err := toD(lookup(document, "fields")); err == nil {
+			if value := lookup(fields, strings.TrimPrefix(key, types.FieldValuesPrefix)); value != nil {
+				values = append(values, value)
+			}
+		}
+
+		return values
+	}
+
+	name, _ := lookup(document, "name").(string)
+
+	var tags []string
+
+	switch array := lookup(document, "tags").(type) {
+	case []string:
+		tags = array
+	case bson.A:
+		for _, tag := range array {
+			if tag, ok := tag.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	for _, value := range types.UniqueValues(key, name, tags) {
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// valueKey returns the key of a unique value in a map, also telling apart
+// values of different types.
+func valueKey(value interface{}) string {
+	return fmt.Sprintf("%T %v", value, value)
+}
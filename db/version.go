@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VersionField is the version of an object, 1 on creation and incremented by
+// every update, so that concurrent updates can be detected.
+//
+// Objects created before versions were introduced have no VersionField, which
+// counts as version 0.
+const VersionField = "version"
+
+// versionedCollections are the collections whose objects have a version.
+var versionedCollections = map[string]bool{
+	"components": true,
+	"assemblies": true,
+	"kits":       true,
+	"locations":  true,
+}
+
+// newObject returns document as a bson document, with its initial version.
+func newObject(document interface{}) (bson.D, error) {
+	d, err := toD(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return set(d, VersionField, int64(1)), nil
+}
+
+// newObjects is newObject for every document.
+func newObjects(documents []interface{}) ([]interface{}, error) {
+	objects := make([]interface{}, len(documents))
+
+	for i, document := range documents {
+		object, err := newObject(document)
+		if err != nil {
+			return nil, err
+		}
+
+		objects[i] = object
+	}
+
+	return objects, nil
+}
+
+// withVersionIncrement returns data, incrementing the version of the updated
+// objects if collection is versioned.
+func withVersionIncrement(collection string, data bson.D) bson.D {
+	if !versionedCollections[collection] {
+		return data
+	}
+
+	update := make(bson.D, 0, len(data)+1)
+
+	for _, element := range data {
+		if element.Key != "$inc" {
+			update = append(update, element)
+		}
+	}
+
+	inc := bson.D{{Key: VersionField, Value: int64(1)}}
+
+	if current, ok := lookup(data, "$inc").(bson.D); ok {
+		inc = append(current, inc...)
+	}
+
+	return append(update, bson.E{Key: "$inc", Value: inc})
+}
+
+// Version returns the version of document, 0 if it has none.
+func Version(document bson.M) int64 {
+	version, _ := toFloat(document[VersionField])
+	return int64(version)
+}
+
+// VersionQuery returns the query selecting the documents whose version is
+// version.
+func VersionQuery(version int64) bson.D {
+	if version == 0 {
+		return bson.D{{Key: VersionField, Value: bson.D{{Key: "$exists", Value: false}}}}
+	}
+
+	return bson.D{{Key: VersionField, Value: version}}
+}
+
+// UpdateIfVersion applies data to the object id of collection only if its
+// version is still version. The returned MatchedCount is 0 otherwise.
+func UpdateIfVersion(ctx context.Context, store Store, collection string, id primitive.ObjectID, version int64, data bson.D) (*mongo.UpdateResult, error) {
+	return store.UpdateMany(ctx, collection, And(bson.D{{Key: "_id", Value: id}}, VersionQuery(version)), data)
+}
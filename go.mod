@@ -8,6 +8,7 @@ require (
 	github.com/labstack/echo/v4 v4.10.2
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/viper v1.15.0
+	go.etcd.io/bbolt v1.3.7
 	go.mongodb.org/mongo-driver v1.11.3
 )
 
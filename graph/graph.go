@@ -9,9 +9,13 @@ import (
 	"codeberg.org/haulproject/haul/types"
 	"github.com/goccy/go-graphviz"
 	"github.com/goccy/go-graphviz/cgraph"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func GetGraph(format graphviz.Format, components types.ComponentsWithID, assemblies types.AssembliesWithID, kits types.KitsWithID) (*bytes.Buffer, error) {
+// GetGraph renders the objects, with an edge from each object to its target,
+// and the locations as clusters around the objects they contain. Objects with
+// a target are in the cluster of their target.
+func GetGraph(format graphviz.Format, components types.ComponentsWithID, assemblies types.AssembliesWithID, kits types.KitsWithID, locations types.LocationsWithID) (*bytes.Buffer, error) {
 	g := graphviz.New()
 
 	graph, err := g.Graph()
@@ -29,10 +33,69 @@ func GetGraph(format graphviz.Format, components types.ComponentsWithID, assembl
 
 	graph.SetLabel("haul graph")
 
+	// clusters
+
+	byID := map[primitive.ObjectID]types.LocationWithID{}
+	for _, location := range locations.LocationsWithID {
+		byID[location.ID] = location
+	}
+
+	clusters := map[primitive.ObjectID]*cgraph.Graph{}
+	visiting := map[primitive.ObjectID]bool{}
+
+	// cluster returns the cluster of the location id, inside the cluster of
+	// its parent, or the graph itself if id is not a known location
+	var cluster func(id primitive.ObjectID) *cgraph.Graph
+
+	cluster = func(id primitive.ObjectID) *cgraph.Graph {
+		if c, ok := clusters[id]; ok {
+			return c
+		}
+
+		location, ok := byID[id]
+		if !ok || visiting[id] {
+			return graph
+		}
+
+		visiting[id] = true
+
+		// Graphviz draws the subgraphs named "cluster_*" as boxes
+		c := cluster(location.Parent).SubGraph("cluster_"+id.Hex(), 1)
+		c.SetLabel(fmt.Sprintf("%s (%s)", location.Name, location.Kind))
+
+		clusters[id] = c
+		return c
+	}
+
+	for _, location := range locations.LocationsWithID {
+		cluster(location.ID)
+	}
+
+	// Objects with a target are at the location of their target
+	located := map[primitive.ObjectID]primitive.ObjectID{}
+
+	for _, kit := range kits.KitsWithID {
+		located[kit.ID] = kit.Location
+	}
+
+	for _, assembly := range assemblies.AssembliesWithID {
+		located[assembly.ID] = assembly.Location
+		if !assembly.Target.IsZero() {
+			located[assembly.ID] = located[assembly.Target]
+		}
+	}
+
+	for _, component := range components.ComponentsWithID {
+		located[component.ID] = component.Location
+		if !component.Target.IsZero() {
+			located[component.ID] = located[component.Target]
+		}
+	}
+
 	// nodes
 
 	for _, component := range components.ComponentsWithID {
-		c, err := graph.CreateNode(component.ID.String())
+		c, err := cluster(located[component.ID]).CreateNode(component.ID.String())
 		if err != nil {
 			return nil, err
 		}
@@ -60,7 +123,7 @@ func GetGraph(format graphviz.Format, components types.ComponentsWithID, assembl
 	}
 
 	for _, assembly := range assemblies.AssembliesWithID {
-		a, err := graph.CreateNode(assembly.ID.String())
+		a, err := cluster(located[assembly.ID]).CreateNode(assembly.ID.String())
 		if err != nil {
 			return nil, err
 		}
@@ -90,7 +153,7 @@ func GetGraph(format graphviz.Format, components types.ComponentsWithID, assembl
 	}
 
 	for _, kit := range kits.KitsWithID {
-		k, err := graph.CreateNode(kit.ID.String())
+		k, err := cluster(located[kit.ID]).CreateNode(kit.ID.String())
 		if err != nil {
 			return nil, err
 		}
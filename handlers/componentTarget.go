@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"codeberg.org/haulproject/haul/db"
@@ -13,70 +13,57 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func HandleV1ComponentTarget(c echo.Context) error {
+func (h *Handler) HandleV1ComponentTarget(c echo.Context) error {
 	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "components", componentID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 	}
 
-	result, err := db.ReadFromID("components", componentID)
 	if err != nil || result == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
+	setETag(c, result)
+
 	for key, value := range result {
 		if key == "target" {
 			return c.JSON(http.StatusOK, value)
 		}
 	}
 
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"message": fmt.Sprintf("Could not find target for object %s", componentID),
-	})
+	return errorJSON(c, http.StatusNotFound, fmt.Sprintf("Could not find target for object %s", componentID))
 }
 
-func HandleV1ComponentTargetUnset(c echo.Context) error {
+func (h *Handler) HandleV1ComponentTargetUnset(c echo.Context) error {
 	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	empty, err := primitive.ObjectIDFromHex("000000000000000000000000")
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -86,63 +73,55 @@ func HandleV1ComponentTargetUnset(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("components", componentID, update)
+	result, err := h.updateFromID(c, "components", componentID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
-func HandleV1ComponentTargetSet(c echo.Context) error {
+func (h *Handler) HandleV1ComponentTargetSet(c echo.Context) error {
 	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-			"error":   err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var data string
 
 	err = c.Bind(&data)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-			"error":   err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON string ObjectID")
 	}
 
 	target, err := primitive.ObjectIDFromHex(data)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-			"error":   err.Error(),
-		})
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validateTarget(c.Request().Context(), "components", componentID, target); err != nil {
+		return statusErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -152,27 +131,26 @@ func HandleV1ComponentTargetSet(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("components", componentID, update)
+	result, err := h.updateFromID(c, "components", componentID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
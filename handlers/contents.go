@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseAt returns the time of the "at" query parameter, as RFC 3339 or as a
+// date, or now when it is empty.
+func parseAt(c echo.Context) (time.Time, error) {
+	at := c.QueryParam("at")
+	if at == "" {
+		return time.Now().UTC(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", at); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid at '%s', must be a RFC 3339 time (e.g. 2026-05-01T00:00:00Z) or a date (e.g. 2026-05-01)", at)
+}
+
+// readAllAt returns every document of collection, or only those of ids
+// unless nil, as it was at the given time, or as it is now when at is empty,
+// except those in the trash.
+func (h *Handler) readAllAt(ctx context.Context, collection string, at string, t time.Time, ids []primitive.ObjectID) ([]*bson.M, error) {
+	if at == "" {
+		filter := db.NotTrashed()
+
+		if ids != nil {
+			in := bson.A{}
+			for _, id := range ids {
+				in = append(in, id)
+			}
+
+			filter = db.And(bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: in}}}}, filter)
+		}
+
+		return h.Store.ReadAll(ctx, collection, filter, nil)
+	}
+
+	return db.ReadAllAt(ctx, h.Store, collection, t, ids)
+}
+
+// HandleV1Snapshot responds with every component, assembly, kit and location
+// as they were at the time of the "at" query parameter.
+func (h *Handler) HandleV1Snapshot(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	at, err := parseAt(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	snapshot := map[string]interface{}{"at": at}
+
+	for _, collection := range []string{"components", "assemblies", "kits", "locations"} {
+		documents, err := h.readAllAt(ctx, collection, c.QueryParam("at"), at, nil)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		snapshot[collection] = documents
+	}
+
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// HandleV1KitContents responds with the kit and every assembly and
+// component that targeted it, directly or through an assembly, at the time
+// of the "at" query parameter.
+func (h *Handler) HandleV1KitContents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
+	if err != nil {
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	at, err := parseAt(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	kits, err := h.readAllAt(ctx, "kits", c.QueryParam("at"), at, []primitive.ObjectID{kitID})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if len(kits) == 0 {
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID at this time")
+	}
+
+	kit := kits[0]
+
+	objects := map[string][]*bson.M{}
+
+	for _, collection := range []string{"components", "assemblies"} {
+		objects[collection], err = h.readAllAt(ctx, collection, c.QueryParam("at"), at, nil)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+	}
+
+	// Objects of the tree, by id
+	contained := map[primitive.ObjectID]bool{kitID: true}
+
+	contents := map[string][]*bson.M{
+		"assemblies": {},
+		"components": {},
+	}
+
+	// Walk down the targets until no object is added to the tree
+	for added := true; added; {
+		added = false
+
+		for collection := range contents {
+			for _, document := range objects[collection] {
+				id, _ := (*document)["_id"].(primitive.ObjectID)
+				target, _ := (*document)["target"].(primitive.ObjectID)
+
+				if contained[id] || !contained[target] {
+					continue
+				}
+
+				contained[id] = true
+				contents[collection] = append(contents[collection], document)
+				added = true
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"at":         at,
+		"kit":        kit,
+		"assemblies": contents["assemblies"],
+		"components": contents["components"],
+	})
+}
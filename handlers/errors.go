@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errorCodes are the codes of the types.Error responded with each status.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            types.ErrorCodeBadRequest,
+	http.StatusUnauthorized:          types.ErrorCodeUnauthorized,
+	http.StatusForbidden:             types.ErrorCodeForbidden,
+	http.StatusNotFound:              types.ErrorCodeNotFound,
+	http.StatusMethodNotAllowed:      types.ErrorCodeMethodNotAllowed,
+	http.StatusConflict:              types.ErrorCodeConflict,
+	http.StatusPreconditionFailed:    types.ErrorCodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: types.ErrorCodeTooLarge,
+	http.StatusUnsupportedMediaType:  types.ErrorCodeUnsupportedMedia,
+	http.StatusUnprocessableEntity:   types.ErrorCodeUnprocessable,
+	http.StatusInternalServerError:   types.ErrorCodeInternal,
+	http.StatusServiceUnavailable:    types.ErrorCodeUnavailable,
+	http.StatusGatewayTimeout:        types.ErrorCodeTimeout,
+}
+
+// errorJSON responds with a types.Error of the given status and message.
+func errorJSON(c echo.Context, status int, message string) error {
+	return errorDetailsJSON(c, status, message, nil)
+}
+
+// errorDetailsJSON responds with a types.Error of the given status, message
+// and details.
+func errorDetailsJSON(c echo.Context, status int, message string, details interface{}) error {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = types.ErrorCodeInternal
+	}
+
+	return c.JSON(status, types.Error{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	})
+}
+
+// statusError is an error to respond with, along with its HTTP status, such
+// as a target refused by validateTarget.
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// detailsError is a statusError responded along with details, such as the
+// types.DuplicateDetails of a unique value already used.
+type detailsError struct {
+	statusError
+	details interface{}
+}
+
+// notFound returns the error responded when the object id does not exist.
+func notFound(id primitive.ObjectID) error {
+	return &statusError{http.StatusNotFound, fmt.Sprintf("No document with ObjectID %s", id.Hex())}
+}
+
+// bindErrorJSON logs the error of binding the body of the request, and
+// responds with a bad request describing the expected body instead of the
+// message of the decoder.
+func bindErrorJSON(c echo.Context, err error, expected string) error {
+	log.Printf("[%s] %s", c.Response().Header().Get(echo.HeaderXRequestID), err)
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) && he.Code == http.StatusUnsupportedMediaType {
+		return errorJSON(c, he.Code, "Body must be of type application/json")
+	}
+
+	return errorJSON(c, http.StatusBadRequest, "Body must be "+expected)
+}
+
+// statusErrorJSON responds with the status and message of a *statusError, or
+// a *detailsError, or with an internal server error for any other error.
+func statusErrorJSON(c echo.Context, err error) error {
+	var d *detailsError
+	if errors.As(err, &d) {
+		return errorDetailsJSON(c, d.status, d.message, d.details)
+	}
+
+	var e *statusError
+	if errors.As(err, &e) {
+		return errorJSON(c, e.status, e.message)
+	}
+
+	return internalErrorJSON(c, err)
+}
+
+// internalErrorJSON logs err and responds with an internal server error,
+// or with a timeout if the deadline of the request was exceeded. A write
+// refused by a unique key is responded as a conflict instead.
+func internalErrorJSON(c echo.Context, err error) error {
+	var duplicate *db.DuplicateError
+	if errors.As(err, &duplicate) {
+		return duplicateJSON(c, duplicate)
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		return errorJSON(c, http.StatusConflict, "A unique value is already used by another object")
+	}
+
+	log.Printf("[%s] %s", c.Response().Header().Get(echo.HeaderXRequestID), err)
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorJSON(c, http.StatusGatewayTimeout, "The request took too long, see 'server.timeout'")
+	}
+
+	return errorJSON(c, http.StatusInternalServerError, "Internal server error")
+}
+
+// HTTPErrorHandler responds with a types.Error to the errors returned by
+// echo and its middlewares, such as unknown routes or invalid keys.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		err = internalErrorJSON(c, err)
+	} else {
+		if he.Internal != nil {
+			log.Printf("[%s] %s", c.Response().Header().Get(echo.HeaderXRequestID), he.Internal)
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(he.Code)
+		} else {
+			err = errorJSON(c, he.Code, fmt.Sprint(he.Message))
+		}
+	}
+
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -3,44 +3,60 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sort"
+	"strings"
+	"time"
 
 	"codeberg.org/haulproject/haul/db"
 	"codeberg.org/haulproject/haul/types"
 	"github.com/labstack/echo/v4"
-	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Handler holds the dependencies shared by the API route handlers.
+type Handler struct {
+	Store db.Store
+
+	// TrashRetention is how long deleted objects stay in the trash before
+	// they are purged, or 0 to keep them until purged by hand.
+	TrashRetention time.Duration
+
+	// Statuses are the allowed statuses and transitions, any status is
+	// allowed when nil.
+	Statuses *types.Statuses
+
+	// Unique are the keys whose values objects of a kind cannot share, as
+	// enforced by the Store. Names and tags may be shared when nil.
+	Unique *types.UniqueRules
+}
+
+// New returns a Handler using store for every database operation.
+func New(store db.Store) *Handler {
+	return &Handler{Store: store}
+}
+
 // Misc
 
-func HandleV1(c echo.Context) error {
+func (h *Handler) HandleV1(c echo.Context) error {
 	routes := c.Echo().Routes()
 	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
 	return c.JSON(http.StatusOK, routes)
 }
 
-func HandleV1Healthcheck(c echo.Context) error {
-	// Vars
-
-	mongoUri := viper.GetString("mongo.uri")
-
-	// Execution
-
+func (h *Handler) HandleV1Healthcheck(c echo.Context) error {
 	// Send a ping to confirm a successful connection
-	_, err := db.Ping(mongoUri)
+	_, err := h.Store.Ping(c.Request().Context())
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"status":        "Internal server error",
+		return errorDetailsJSON(c, http.StatusServiceUnavailable, "The database cannot be reached", map[string]string{
 			"ping_database": "not ok",
 		})
-
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -51,23 +67,60 @@ func HandleV1Healthcheck(c echo.Context) error {
 
 // Create
 
-func HandleV1ComponentCreate(c echo.Context) error {
+func (h *Handler) HandleV1ComponentCreate(c echo.Context) error {
 	var components types.Components
 
 	err := c.Bind(&components.Components)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "error",
-			"error":   err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON array of components")
 	}
 
-	result, err := db.CreateComponents(components)
+	schema, err := h.schema(c.Request().Context(), "components")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error during db.CreateComponents",
-			"error":   err.Error(),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, component := range components.Components {
+		if component.Name == "" {
+			return errorJSON(c, http.StatusUnprocessableEntity, "name cannot be empty")
+		}
+
+		if err := h.validateTarget(c.Request().Context(), "components", primitive.NilObjectID, component.Target); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.validatePlacement(c.Request().Context(), component.Target, component.Location); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.Statuses.Validate(component.Status); err != nil {
+			return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+		}
+
+		components.Components[i].Fields, err = h.validateNewFields(c.Request().Context(), "components", schema, component.Fields, seen)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+	}
+
+	result, err := h.Store.CreateComponents(c.Request().Context(), components)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	// The stock history of a component starts with its initial quantity
+	for i, component := range components.Components {
+		if component.Quantity == nil || *component.Quantity == 0 || i >= len(result.InsertedIDs) {
+			continue
+		}
+
+		componentID, _ := result.InsertedIDs[i].(primitive.ObjectID)
+
+		if _, err := db.RecordStockMovement(c.Request().Context(), h.Store, componentID, *component.Quantity, *component.Quantity, "Initial quantity"); err != nil {
+			return internalErrorJSON(c, err)
+		}
 	}
 
 	if len(result.InsertedIDs) == 0 {
@@ -84,23 +137,47 @@ func HandleV1ComponentCreate(c echo.Context) error {
 	})
 }
 
-func HandleV1AssemblyCreate(c echo.Context) error {
+func (h *Handler) HandleV1AssemblyCreate(c echo.Context) error {
 	var assemblies types.Assemblies
 
 	err := c.Bind(&assemblies.Assemblies)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "error",
-			"error":   err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON array of assemblies")
 	}
 
-	result, err := db.CreateAssemblies(assemblies)
+	schema, err := h.schema(c.Request().Context(), "assemblies")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error during db.CreateAssemblies",
-			"error":   err.Error(),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, assembly := range assemblies.Assemblies {
+		if assembly.Name == "" {
+			return errorJSON(c, http.StatusUnprocessableEntity, "name cannot be empty")
+		}
+
+		if err := h.validateTarget(c.Request().Context(), "assemblies", primitive.NilObjectID, assembly.Target); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.validatePlacement(c.Request().Context(), assembly.Target, assembly.Location); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.Statuses.Validate(assembly.Status); err != nil {
+			return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+		}
+
+		assemblies.Assemblies[i].Fields, err = h.validateNewFields(c.Request().Context(), "assemblies", schema, assembly.Fields, seen)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+	}
+
+	result, err := h.Store.CreateAssemblies(c.Request().Context(), assemblies)
+	if err != nil {
+		return internalErrorJSON(c, err)
 	}
 
 	if len(result.InsertedIDs) == 0 {
@@ -117,23 +194,43 @@ func HandleV1AssemblyCreate(c echo.Context) error {
 	})
 }
 
-func HandleV1KitCreate(c echo.Context) error {
+func (h *Handler) HandleV1KitCreate(c echo.Context) error {
 	var kits types.Kits
 
 	err := c.Bind(&kits.Kits)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "error",
-			"error":   err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON array of kits")
 	}
 
-	result, err := db.CreateKits(kits)
+	schema, err := h.schema(c.Request().Context(), "kits")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error during db.CreateKits",
-			"error":   err.Error(),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, kit := range kits.Kits {
+		if kit.Name == "" {
+			return errorJSON(c, http.StatusUnprocessableEntity, "name cannot be empty")
+		}
+
+		if err := h.Statuses.Validate(kit.Status); err != nil {
+			return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+		}
+
+		if err := h.validateLocation(c.Request().Context(), kit.Location); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		kits.Kits[i].Fields, err = h.validateNewFields(c.Request().Context(), "kits", schema, kit.Fields, seen)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+	}
+
+	result, err := h.Store.CreateKits(c.Request().Context(), kits)
+	if err != nil {
+		return internalErrorJSON(c, err)
 	}
 
 	if len(result.InsertedIDs) == 0 {
@@ -150,166 +247,411 @@ func HandleV1KitCreate(c echo.Context) error {
 	})
 }
 
+func (h *Handler) HandleV1LocationCreate(c echo.Context) error {
+	var locations types.Locations
+
+	err := c.Bind(&locations.Locations)
+	if err != nil {
+		return bindErrorJSON(c, err, "a JSON array of locations")
+	}
+
+	documents := make([]interface{}, len(locations.Locations))
+
+	schema, err := h.schema(c.Request().Context(), "locations")
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	seen := map[string]bool{}
+
+	for i, location := range locations.Locations {
+		if err := h.validateParent(c.Request().Context(), primitive.NilObjectID, location.Kind, location.Parent); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		location.Fields, err = h.validateNewFields(c.Request().Context(), "locations", schema, location.Fields, seen)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		documents[i] = location
+	}
+
+	if len(documents) == 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message":      "Nothing to do",
+			"inserted_ids": []interface{}{},
+		})
+	}
+
+	result, err := h.Store.InsertMany(c.Request().Context(), "locations", documents)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":      "Created locations",
+		"inserted_ids": result.InsertedIDs,
+	})
+}
+
 // Read
 
-func HandleV1ComponentRead(c echo.Context) error {
+func (h *Handler) HandleV1ComponentRead(c echo.Context) error {
 	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "components", componentID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 	}
 
-	result, err := db.ReadFromID("components", componentID)
 	if err != nil || result == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
+	setETag(c, result)
+
 	return c.JSON(http.StatusOK, result)
 }
 
-func HandleV1AssemblyRead(c echo.Context) error {
+func (h *Handler) HandleV1AssemblyRead(c echo.Context) error {
 	assemblyID, err := primitive.ObjectIDFromHex(c.Param("assembly"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "assemblies", assemblyID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 	}
 
-	result, err := db.ReadFromID("assemblies", assemblyID)
 	if err != nil || result == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
+	setETag(c, result)
+
 	return c.JSON(http.StatusOK, result)
 }
 
-func HandleV1KitRead(c echo.Context) error {
+func (h *Handler) HandleV1KitRead(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "kits", kitID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 	}
 
-	result, err := db.ReadFromID("kits", kitID)
 	if err != nil || result == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	setETag(c, result)
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) HandleV1LocationRead(c echo.Context) error {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("location"))
+	if err != nil {
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "locations", locationID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
+	}
+
+	if err != nil || result == nil {
+		// ErrNoDocuments means that the filter did not match any documents in
+		// the collection.
+		if err == mongo.ErrNoDocuments {
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
+		}
+
+		// other
+		return internalErrorJSON(c, err)
 	}
 
+	setETag(c, result)
+
 	return c.JSON(http.StatusOK, result)
 }
 
 // List
 
-func HandleV1ComponentList(c echo.Context) error {
-	components, err := db.ReadAll("components")
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+func (h *Handler) HandleV1ComponentList(c echo.Context) error {
+	return h.list(c, "components", types.Component{})
+}
+
+func (h *Handler) HandleV1AssemblyList(c echo.Context) error {
+	return h.list(c, "assemblies", types.Assembly{})
+}
+
+func (h *Handler) HandleV1KitList(c echo.Context) error {
+	return h.list(c, "kits", types.Kit{})
+}
+
+func (h *Handler) HandleV1LocationList(c echo.Context) error {
+	return h.list(c, "locations", types.Location{})
+}
+
+// list responds with a page of the documents in collection selected by the
+// types.Filter and types.ListOptions query parameters of the request.
+//
+// The documents are returned under the collection name, along with the
+// fields of types.Page. Sorting and projection are only allowed on the
+// fields of reference and on "_id". Sorting is also allowed on the custom
+// fields of the schema of the collection, e.g. "fields.price".
+func (h *Handler) list(c echo.Context, collection string, reference interface{}) error {
+	schema, err := h.schema(c.Request().Context(), collection)
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	return c.JSON(http.StatusOK, components)
-}
+	filter, err := filterQuery(c, schema)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	// Objects in the trash are listed by HandleV1Trash
+	filter = db.And(filter, db.NotTrashed())
+
+	var listOptions types.ListOptions
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &listOptions); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
 
-func HandleV1AssemblyList(c echo.Context) error {
-	assemblies, err := db.ReadAll("assemblies")
+	limit := listOptions.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	if limit < 0 || limit > maxListLimit {
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+	}
+
+	fields, err := types.GetFields(reference)
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	return c.JSON(http.StatusOK, assemblies)
-}
+	fields = append(fields, "_id")
+
+	sortFields := fields
 
-func HandleV1KitList(c echo.Context) error {
-	kits, err := db.ReadAll("kits")
+	for _, name := range schema.Names() {
+		sortFields = append(sortFields, "fields."+name)
+	}
+
+	order, err := db.SortOrder(listOptions.Sort)
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	for _, field := range order {
+		if !contains(sortFields, field.Key) {
+			return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Cannot sort on unknown field '%s'", field.Key))
+		}
 	}
 
-	return c.JSON(http.StatusOK, kits)
+	var projection []string
+
+	if listOptions.Fields != "" {
+		for _, field := range strings.Split(listOptions.Fields, ",") {
+			field = strings.TrimSpace(field)
+
+			if !contains(fields, field) {
+				return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Cannot return unknown field '%s'", field))
+			}
+
+			projection = append(projection, field)
+		}
+	}
+
+	// The sort fields are needed to build the next cursor, even if they are
+	// not part of the requested fields
+	readProjection := projection
+	if len(projection) > 0 {
+		for _, field := range order {
+			// Custom fields are read along with the others
+			key, _, _ := strings.Cut(field.Key, ".")
+
+			if !contains(readProjection, key) {
+				readProjection = append(readProjection, key)
+			}
+		}
+	}
+
+	query := filter
+
+	if listOptions.After != "" {
+		after, err := db.CursorQuery(order, listOptions.After)
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		query = db.And(filter, after)
+	}
+
+	// One more document than the limit is read, to know if there is a next page
+	documents, err := h.Store.ReadAll(c.Request().Context(), collection, query, &db.ReadOptions{
+		Sort:       order,
+		Limit:      limit + 1,
+		Projection: readProjection,
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	total, err := h.Store.Count(c.Request().Context(), collection, filter)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	page := types.Page{Total: total}
+
+	if int64(len(documents)) > limit {
+		documents = documents[:limit]
+
+		page.Next, err = db.Cursor(order, *documents[limit-1])
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+	}
+
+	if len(projection) > 0 {
+		for _, document := range documents {
+			for key := range *document {
+				if key != "_id" && !contains(projection, key) {
+					delete(*document, key)
+				}
+			}
+		}
+	}
+
+	if documents == nil {
+		documents = []*bson.M{}
+	}
+
+	response := map[string]interface{}{
+		collection: documents,
+		"total":    page.Total,
+	}
+
+	if page.Next != "" {
+		response["next"] = page.Next
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterQuery returns the query described by the types.Filter query
+// parameters of the request. Custom fields are filtered by schema, which is
+// nil when the objects are of several kinds.
+func filterQuery(c echo.Context, schema *types.Schema) (bson.D, error) {
+	var filter types.Filter
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		return nil, err
+	}
+
+	for name, values := range c.QueryParams() {
+		if strings.HasPrefix(name, types.TagValuesPrefix) {
+			if filter.TagValues == nil {
+				filter.TagValues = map[string][]string{}
+			}
+
+			key := strings.TrimPrefix(name, types.TagValuesPrefix)
+			filter.TagValues[key] = append(filter.TagValues[key], values...)
+		}
+
+		if strings.HasPrefix(name, types.FieldValuesPrefix) {
+			if filter.FieldValues == nil {
+				filter.FieldValues = map[string][]string{}
+			}
+
+			key := strings.TrimPrefix(name, types.FieldValuesPrefix)
+			filter.FieldValues[key] = append(filter.FieldValues[key], values...)
+		}
+	}
+
+	return db.FilterQuery(filter, schema)
 }
 
 // Update
 
-func HandleV1ComponentUpdate(c echo.Context) error {
+func (h *Handler) HandleV1ComponentUpdate(c echo.Context) error {
 	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var data interface{}
@@ -317,40 +659,45 @@ func HandleV1ComponentUpdate(c echo.Context) error {
 	err = c.Bind(&data)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	marshalled, err := bson.Marshal(data)
 
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 	var component bson.D
 	err = bson.Unmarshal(marshalled, &component)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	validated, err := types.ValidateFields(component, types.Component{})
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Error during fields validation",
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	if validated == nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "No valid data to use in update was found, nothing to do",
-		})
+		return errorJSON(c, http.StatusUnprocessableEntity, "No valid data to use in update was found, nothing to do")
+	}
+
+	if err := checkQuantityUnchanged("components", validated, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.updateTarget(c.Request().Context(), "components", componentID, validated); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.updateLocation(c.Request().Context(), "components", componentID, validated, nil, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.validateCustomFields(c.Request().Context(), "components", componentID, validated, nil); err != nil {
+		return statusErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -359,45 +706,39 @@ func HandleV1ComponentUpdate(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("components", componentID, update)
+	result, err := h.updateFromID(c, "components", componentID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
-func HandleV1AssemblyUpdate(c echo.Context) error {
+func (h *Handler) HandleV1AssemblyUpdate(c echo.Context) error {
 	assemblyID, err := primitive.ObjectIDFromHex(c.Param("assembly"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var data interface{}
@@ -405,40 +746,41 @@ func HandleV1AssemblyUpdate(c echo.Context) error {
 	err = c.Bind(&data)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	marshalled, err := bson.Marshal(data)
 
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 	var assembly bson.D
 	err = bson.Unmarshal(marshalled, &assembly)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	validated, err := types.ValidateFields(assembly, types.Assembly{})
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Error during fields validation",
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	if validated == nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "No valid data to use in update was found, nothing to do",
-		})
+		return errorJSON(c, http.StatusUnprocessableEntity, "No valid data to use in update was found, nothing to do")
+	}
+
+	if err := h.updateTarget(c.Request().Context(), "assemblies", assemblyID, validated); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.updateLocation(c.Request().Context(), "assemblies", assemblyID, validated, nil, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.validateCustomFields(c.Request().Context(), "assemblies", assemblyID, validated, nil); err != nil {
+		return statusErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -447,45 +789,39 @@ func HandleV1AssemblyUpdate(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("assemblies", assemblyID, update)
+	result, err := h.updateFromID(c, "assemblies", assemblyID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
-func HandleV1KitUpdate(c echo.Context) error {
+func (h *Handler) HandleV1KitUpdate(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var data interface{}
@@ -493,40 +829,37 @@ func HandleV1KitUpdate(c echo.Context) error {
 	err = c.Bind(&data)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	marshalled, err := bson.Marshal(data)
 
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 	var kit bson.D
 	err = bson.Unmarshal(marshalled, &kit)
 	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Bad request",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
 	validated, err := types.ValidateFields(kit, types.Kit{})
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "Error during fields validation",
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	if validated == nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "No valid data to use in update was found, nothing to do",
-		})
+		return errorJSON(c, http.StatusUnprocessableEntity, "No valid data to use in update was found, nothing to do")
+	}
+
+	if err := h.updateLocation(c.Request().Context(), "kits", kitID, validated, nil, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.validateCustomFields(c.Request().Context(), "kits", kitID, validated, nil); err != nil {
+		return statusErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -535,111 +868,160 @@ func HandleV1KitUpdate(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("kits", kitID, update)
+	result, err := h.updateFromID(c, "kits", kitID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
-// Delete
-
-func HandleV1ComponentDelete(c echo.Context) error {
-	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
+func (h *Handler) HandleV1LocationUpdate(c echo.Context) error {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("location"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
+		return internalErrorJSON(c, err)
+	}
+
+	var data interface{}
+
+	err = c.Bind(&data)
+	if err != nil {
 		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
-	result, err := db.DeleteFromID("components", componentID)
+	marshalled, err := bson.Marshal(data)
+
 	if err != nil {
-		// other
 		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
+	}
+	var location bson.D
+	err = bson.Unmarshal(marshalled, &location)
+	if err != nil {
+		log.Println(err)
+		return errorJSON(c, http.StatusBadRequest, "Bad request")
 	}
 
-	return c.JSON(http.StatusOK, result)
+	validated, err := types.ValidateFields(location, types.Location{})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if validated == nil {
+		return errorJSON(c, http.StatusUnprocessableEntity, "No valid data to use in update was found, nothing to do")
+	}
+
+	if err := h.updateLocation(c.Request().Context(), "locations", locationID, validated, nil, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if err := h.validateCustomFields(c.Request().Context(), "locations", locationID, validated, nil); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	update := bson.D{
+		primitive.E{
+			Key: "$set", Value: validated,
+		},
+	}
+
+	result, err := h.updateFromID(c, "locations", locationID, update, nil)
+	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
+		// ErrNoDocuments means that the filter did not match any documents in
+		// the collection.
+		if err == mongo.ErrNoDocuments {
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
+		}
+
+		// other
+		return internalErrorJSON(c, err)
+	}
+
+	message, err := json.Marshal(result)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
-func HandleV1AssemblyDelete(c echo.Context) error {
-	assemblyID, err := primitive.ObjectIDFromHex(c.Param("assembly"))
+// Delete
+
+func (h *Handler) HandleV1ComponentDelete(c echo.Context) error {
+	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	result, err := db.DeleteFromID("assemblies", assemblyID)
+	return h.delete(c, "components", componentID)
+}
+
+func (h *Handler) HandleV1AssemblyDelete(c echo.Context) error {
+	assemblyID, err := primitive.ObjectIDFromHex(c.Param("assembly"))
 	if err != nil {
-		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return h.delete(c, "assemblies", assemblyID)
 }
 
-func HandleV1KitDelete(c echo.Context) error {
+func (h *Handler) HandleV1KitDelete(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	result, err := db.DeleteFromID("kits", kitID)
+	return h.delete(c, "kits", kitID)
+}
+
+func (h *Handler) HandleV1LocationDelete(c echo.Context) error {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("location"))
 	if err != nil {
-		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return h.deleteLocation(c, locationID)
 }
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KeyNameContextKey is the echo.Context key of the name of the API key used
+// by the request, set by the key authentication.
+const KeyNameContextKey = "key_name"
+
+// Actor is a middleware passing the actor of the request down to the
+// database, so that its changes are recorded in the history.
+//
+// The actor is named after the API key of the request, or "anonymous"
+// without key authentication.
+func Actor(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := db.Actor{Name: "anonymous", RemoteIP: c.RealIP()}
+
+		if name, ok := c.Get(KeyNameContextKey).(string); ok && name != "" {
+			actor.Name = "key:" + name
+		}
+
+		c.SetRequest(c.Request().WithContext(db.WithActor(c.Request().Context(), actor)))
+
+		return next(c)
+	}
+}
+
+func (h *Handler) HandleV1ComponentHistory(c echo.Context) error {
+	return h.history(c, "components", c.Param("component"))
+}
+
+func (h *Handler) HandleV1AssemblyHistory(c echo.Context) error {
+	return h.history(c, "assemblies", c.Param("assembly"))
+}
+
+func (h *Handler) HandleV1KitHistory(c echo.Context) error {
+	return h.history(c, "kits", c.Param("kit"))
+}
+
+func (h *Handler) HandleV1LocationHistory(c echo.Context) error {
+	return h.history(c, "locations", c.Param("location"))
+}
+
+// history responds with the history of the object id of collection, from
+// oldest to newest. The history of deleted objects is kept.
+func (h *Handler) history(c echo.Context, collection string, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	filter := bson.D{
+		{Key: "collection", Value: collection},
+		{Key: "object", Value: objectID},
+	}
+
+	entries, err := db.ReadHistory(c.Request().Context(), h.Store, filter)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	history := types.History{Entries: entries}
+
+	return c.JSON(http.StatusOK, history)
+}
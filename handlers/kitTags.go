@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"codeberg.org/haulproject/haul/db"
@@ -13,62 +13,52 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func HandleV1KitTags(c echo.Context) error {
+func (h *Handler) HandleV1KitTags(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
+	}
+
+	result, err := h.Store.ReadFromID(c.Request().Context(), "kits", kitID)
+	if err == nil && db.IsTrashed(result) {
+		// Objects in the trash are only listed by 'GET /v1/trash'
+		return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 	}
 
-	result, err := db.ReadFromID("kits", kitID)
 	if err != nil || result == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
+	setETag(c, result)
+
 	for key, value := range result {
 		if key == "tags" {
 			return c.JSON(http.StatusOK, value)
 		}
 	}
 
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"message": fmt.Sprintf("Could not find tags for object %s", kitID),
-	})
+	return errorJSON(c, http.StatusNotFound, fmt.Sprintf("Could not find tags for object %s", kitID))
 }
 
-func HandleV1KitTagsClear(c echo.Context) error {
+func (h *Handler) HandleV1KitTagsClear(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	update := bson.D{
@@ -78,63 +68,52 @@ func HandleV1KitTagsClear(c echo.Context) error {
 		},
 	}
 
-	result, err := db.UpdateFromID("kits", kitID, update)
+	result, err := h.updateFromID(c, "kits", kitID, update, nil)
 	if err != nil || result == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(result)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling result",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
 // HandleV1KitTagsAdd
-func HandleV1KitTagsAdd(c echo.Context) error {
+func (h *Handler) HandleV1KitTagsAdd(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	kit, err := db.ReadFromID("kits", kitID)
+	kit, err := h.Store.ReadFromID(c.Request().Context(), "kits", kitID)
 	if err != nil || kit == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var tags_old []string
@@ -144,13 +123,13 @@ func HandleV1KitTagsAdd(c echo.Context) error {
 			tags, ok := value.(primitive.A)
 
 			if !ok && value != nil {
-				return c.JSON(http.StatusInternalServerError, "[err] Could not iterate over tags")
+				return errorJSON(c, http.StatusInternalServerError, "[err] Could not iterate over tags")
 			}
 
 			for _, tag := range tags {
 				tag_string, ok := tag.(string)
 				if !ok {
-					return c.JSON(http.StatusInternalServerError, "Cannot cast tag into string")
+					return errorJSON(c, http.StatusInternalServerError, "Cannot cast tag into string")
 				}
 				tags_old = append(tags_old, tag_string)
 			}
@@ -161,10 +140,7 @@ func HandleV1KitTagsAdd(c echo.Context) error {
 
 	err = c.Bind(&tags_add)
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON array of tags")
 	}
 
 	for _, tag_add := range tags_add {
@@ -179,9 +155,7 @@ func HandleV1KitTagsAdd(c echo.Context) error {
 		if !present {
 			new_tag, ok := tag_add.(string)
 			if !ok {
-				return c.JSON(http.StatusBadRequest, map[string]string{
-					"message": err.Error(),
-				})
+				return errorJSON(c, http.StatusUnprocessableEntity, fmt.Sprintf("Tag %v must be a string", tag_add))
 			}
 			tags_old = append(tags_old, new_tag)
 		}
@@ -195,63 +169,52 @@ func HandleV1KitTagsAdd(c echo.Context) error {
 		},
 	}
 
-	updateResult, err := db.UpdateFromID("kits", kitID, update)
+	updateResult, err := h.updateFromID(c, "kits", kitID, update, kit)
 	if err != nil || updateResult == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(updateResult)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling updateResult",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling updateResult")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
 }
 
 // HandleV1KitTagsRemove
-func HandleV1KitTagsRemove(c echo.Context) error {
+func (h *Handler) HandleV1KitTagsRemove(c echo.Context) error {
 	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
 	if err != nil {
 		if err == primitive.ErrInvalidHex {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": fmt.Sprintf("%s", err),
-			})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": fmt.Sprintf("Internal server error"),
-		})
+		return internalErrorJSON(c, err)
 	}
 
-	kit, err := db.ReadFromID("kits", kitID)
+	kit, err := h.Store.ReadFromID(c.Request().Context(), "kits", kitID)
 	if err != nil || kit == nil {
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Internal server error",
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	var tags_old []string
@@ -262,7 +225,7 @@ func HandleV1KitTagsRemove(c echo.Context) error {
 			tags, ok := value.(primitive.A)
 
 			if !ok {
-				return c.JSON(http.StatusInternalServerError, "[err] Could not iterate over tags")
+				return errorJSON(c, http.StatusInternalServerError, "[err] Could not iterate over tags")
 			}
 
 			tagsFound = true
@@ -270,7 +233,7 @@ func HandleV1KitTagsRemove(c echo.Context) error {
 			for _, tag := range tags {
 				tag_string, ok := tag.(string)
 				if !ok {
-					return c.JSON(http.StatusInternalServerError, "Cannot cast tag into string")
+					return errorJSON(c, http.StatusInternalServerError, "Cannot cast tag into string")
 				}
 				tags_old = append(tags_old, tag_string)
 			}
@@ -278,9 +241,7 @@ func HandleV1KitTagsRemove(c echo.Context) error {
 	}
 
 	if !tagsFound {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"message": fmt.Sprintf("Could not find tags for object %s", kitID),
-		})
+		return errorJSON(c, http.StatusNotFound, fmt.Sprintf("Could not find tags for object %s", kitID))
 	}
 
 	if len(tags_old) == 0 {
@@ -294,10 +255,7 @@ func HandleV1KitTagsRemove(c echo.Context) error {
 
 	err = c.Bind(&tags_remove)
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return bindErrorJSON(c, err, "a JSON array of tags")
 	}
 
 	var tags_new []string
@@ -324,27 +282,26 @@ func HandleV1KitTagsRemove(c echo.Context) error {
 		},
 	}
 
-	updateResult, err := db.UpdateFromID("kits", kitID, update)
+	updateResult, err := h.updateFromID(c, "kits", kitID, update, kit)
 	if err != nil || updateResult == nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
 		// ErrNoDocuments means that the filter did not match any documents in
 		// the collection.
 		if err == mongo.ErrNoDocuments {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"message": "No document with specified ObjectID",
-			})
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
 		}
 
 		// other
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return internalErrorJSON(c, err)
 	}
 
 	message, err := json.Marshal(updateResult)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": "Error marshalling updateResult",
-		})
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling updateResult")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
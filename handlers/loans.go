@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HandleV1KitCheckout lends the kit, as described by a types.Checkout, and
+// responds with the recorded types.Loan. Lending a kit that is already lent
+// is a conflict.
+func (h *Handler) HandleV1KitCheckout(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var data types.Checkout
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with a borrower, a due time and an optional note")
+	}
+
+	data.Borrower = strings.TrimSpace(data.Borrower)
+
+	if data.Borrower == "" {
+		return errorJSON(c, http.StatusUnprocessableEntity, "borrower cannot be empty")
+	}
+
+	if !data.Due.After(time.Now()) {
+		return errorJSON(c, http.StatusUnprocessableEntity, "due must be a time in the future")
+	}
+
+	kit, err := h.readRelative(c, "kits", c.Param("kit"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	kitID, _ := kit["_id"].(primitive.ObjectID)
+
+	if err := h.checkNotLent(ctx, kit); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	// The kit is not lent over the reservations of someone else
+	if !forced(c) {
+		reservations, err := db.ReadReservations(ctx, h.Store, db.And(bson.D{{Key: "kit", Value: kitID}}, db.Overlapping(time.Now(), data.Due)))
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		conflicts := types.ReservationConflicts{Reservations: []types.Reservation{}, Loans: []types.Loan{}}
+
+		for _, reservation := range reservations {
+			if reservation.Holder != data.Borrower {
+				conflicts.Reservations = append(conflicts.Reservations, reservation)
+			}
+		}
+
+		if len(conflicts.Reservations) > 0 {
+			return errorDetailsJSON(c, http.StatusConflict,
+				fmt.Sprintf("Kit %s is reserved by someone else before %s, retry with force=true", kitID.Hex(), data.Due.Format(time.RFC3339)),
+				conflicts,
+			)
+		}
+	}
+
+	loan := types.Loan{
+		ID:         primitive.NewObjectID(),
+		Collection: "kits",
+		Object:     kitID,
+		Borrower:   data.Borrower,
+		Due:        data.Due.UTC(),
+		Note:       data.Note,
+	}
+
+	loan.Name, _ = kit["name"].(string)
+
+	// Only one of concurrent check-outs marks the kit as lent
+	lent, err := db.Lend(ctx, h.Store, kitID, loan.ID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if !lent {
+		return errorJSON(c, http.StatusConflict, fmt.Sprintf("Kit %s was checked out by someone else", kitID.Hex()))
+	}
+
+	recorded, err := db.RecordLoan(ctx, h.Store, loan)
+	if err != nil {
+		// The kit is not lent without its loan
+		if _, undoErr := db.Unlend(ctx, h.Store, kitID, loan.ID); undoErr != nil {
+			log.Printf("[%s] Kit %s is left checked out by loan %s: %s", c.Response().Header().Get(echo.HeaderXRequestID), kitID.Hex(), loan.ID.Hex(), undoErr)
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, recorded)
+}
+
+// HandleV1KitCheckin returns the kit, with the note of an optional
+// types.Checkin, and responds with its returned types.Loan. Returning a kit
+// that is not lent is a conflict.
+func (h *Handler) HandleV1KitCheckin(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var data types.Checkin
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with an optional note")
+	}
+
+	kit, err := h.readRelative(c, "kits", c.Param("kit"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	kitID, _ := kit["_id"].(primitive.ObjectID)
+
+	loanID, lent, err := db.ActiveLoan(ctx, h.Store, kitID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if !lent {
+		return errorJSON(c, http.StatusConflict, fmt.Sprintf("Kit %s is not checked out", kitID.Hex()))
+	}
+
+	// Only one of concurrent check-ins returns the loan
+	returned, err := db.Unlend(ctx, h.Store, kitID, loanID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if !returned {
+		return errorJSON(c, http.StatusConflict, fmt.Sprintf("Kit %s was checked in by someone else", kitID.Hex()))
+	}
+
+	if err := db.ReturnLoan(ctx, h.Store, loanID, data.Note); err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	loans, err := db.ReadLoans(ctx, h.Store, bson.D{{Key: "_id", Value: loanID}}, nil)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if len(loans) == 0 {
+		return errorJSON(c, http.StatusNotFound, fmt.Sprintf("Loan %s of kit %s was not found", loanID.Hex(), kitID.Hex()))
+	}
+
+	return c.JSON(http.StatusOK, loans[0])
+}
+
+// HandleV1KitLoans responds with the loans of the kit, from the oldest
+// check-out. The loans of deleted kits are kept.
+func (h *Handler) HandleV1KitLoans(c echo.Context) error {
+	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	filter := bson.D{
+		{Key: "collection", Value: "kits"},
+		{Key: "object", Value: kitID},
+	}
+
+	loans, err := db.ReadLoans(c.Request().Context(), h.Store, filter, bson.D{{Key: "checked_out_at", Value: 1}})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, types.Loans{Loans: loans})
+}
+
+// HandleV1Loans responds with the loans selected by the types.LoanFilter
+// query parameters of the request, from the earliest due date.
+func (h *Handler) HandleV1Loans(c echo.Context) error {
+	var filter types.LoanFilter
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	query := bson.D{}
+
+	if filter.Active || filter.Overdue {
+		query = append(query, bson.E{Key: "returned_at", Value: bson.D{{Key: "$exists", Value: false}}})
+	}
+
+	if filter.Borrower != "" {
+		query = append(query, bson.E{Key: "borrower", Value: filter.Borrower})
+	}
+
+	loans, err := db.ReadLoans(c.Request().Context(), h.Store, query, nil)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	selected := []types.Loan{}
+	now := time.Now()
+
+	for _, loan := range loans {
+		if !filter.Overdue || loan.Overdue(now) {
+			selected = append(selected, loan)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Due.Before(selected[j].Due)
+	})
+
+	return c.JSON(http.StatusOK, types.Loans{Loans: selected})
+}
+
+// forced reports whether the "force" query parameter of the request allows
+// changing lent kits, see checkNotLent.
+func forced(c echo.Context) bool {
+	return c.QueryParam("force") == "true"
+}
+
+// containingKit returns the kit document of collection is, or is contained
+// in through its targets, or nil if it is in no kit.
+func (h *Handler) containingKit(ctx context.Context, collection string, document bson.M) (bson.M, error) {
+	visited := map[primitive.ObjectID]bool{}
+
+	for collection != "kits" {
+		target, _ := document["target"].(primitive.ObjectID)
+		if target.IsZero() || visited[target] {
+			return nil, nil
+		}
+
+		visited[target] = true
+
+		var err error
+		collection, document, err = h.findObject(ctx, target)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return document, nil
+}
+
+// checkNotLent returns a *statusError if kit is lent.
+func (h *Handler) checkNotLent(ctx context.Context, kit bson.M) error {
+	kitID, _ := kit["_id"].(primitive.ObjectID)
+
+	loanID, lent, err := db.ActiveLoan(ctx, h.Store, kitID)
+	if err != nil || !lent {
+		return err
+	}
+
+	loans, err := db.ReadLoans(ctx, h.Store, bson.D{{Key: "_id", Value: loanID}}, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(loans) == 0 {
+		return &statusError{http.StatusConflict, fmt.Sprintf("Kit %s is checked out, check it in first or retry with force=true", kitID.Hex())}
+	}
+
+	return &statusError{http.StatusConflict, fmt.Sprintf("Kit %s is checked out to %s until %s, check it in first or retry with force=true", kitID.Hex(), loans[0].Borrower, loans[0].Due.Format(time.RFC3339))}
+}
+
+// checkNotLentKit returns a *statusError if document of collection is a lent
+// kit, or is contained in one.
+func (h *Handler) checkNotLentKit(ctx context.Context, collection string, document bson.M) error {
+	kit, err := h.containingKit(ctx, collection, document)
+	if err != nil || kit == nil {
+		return err
+	}
+
+	return h.checkNotLent(ctx, kit)
+}
+
+// checkMoveOut returns a *statusError if the update data moves the object id
+// of collection out of a lent kit, unless the request is forced. Objects may
+// still move within their kit.
+//
+// current is read if nil and needed, and returned.
+func (h *Handler) checkMoveOut(c echo.Context, collection string, id primitive.ObjectID, data bson.D, current bson.M) (bson.M, error) {
+	ctx := c.Request().Context()
+
+	target, changed := objectIDChange(data, "target")
+	if !changed || forced(c) {
+		return current, nil
+	}
+
+	if current == nil {
+		var err error
+		if current, err = h.Store.ReadFromID(ctx, collection, id); err == mongo.ErrNoDocuments {
+			return nil, notFound(id)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	kit, err := h.containingKit(ctx, collection, current)
+	if err != nil || kit == nil {
+		return current, err
+	}
+
+	kitID, _ := kit["_id"].(primitive.ObjectID)
+
+	if _, lent, err := db.ActiveLoan(ctx, h.Store, kitID); err != nil || !lent {
+		return current, err
+	}
+
+	if !target.IsZero() {
+		targetCollection, targetDocument, err := h.findObject(ctx, target)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		if err == nil {
+			targetKit, err := h.containingKit(ctx, targetCollection, targetDocument)
+			if err != nil {
+				return nil, err
+			}
+
+			if targetKit != nil && targetKit["_id"] == kit["_id"] {
+				return current, nil
+			}
+		}
+	}
+
+	return nil, h.checkNotLent(ctx, kit)
+}
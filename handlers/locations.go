@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// locatedCollections are the collections whose objects may have a location,
+// in the order they are listed in the contents of a location.
+var locatedCollections = []string{"kits", "assemblies", "components"}
+
+// findLocation returns the location id, or mongo.ErrNoDocuments if it does
+// not exist or is in the trash.
+func (h *Handler) findLocation(ctx context.Context, id primitive.ObjectID) (bson.M, error) {
+	document, err := h.Store.ReadFromID(ctx, "locations", id)
+	if err == nil && db.IsTrashed(document) {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return document, err
+}
+
+// validateLocation returns a *statusError if location does not exist. A zero
+// location, which unsets the location, is always valid.
+func (h *Handler) validateLocation(ctx context.Context, location primitive.ObjectID) error {
+	if location.IsZero() {
+		return nil
+	}
+
+	_, err := h.findLocation(ctx, location)
+	if err == mongo.ErrNoDocuments {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Location %s does not exist", location.Hex())}
+	}
+
+	return err
+}
+
+// validatePlacement returns a *statusError if a new object with target
+// cannot be at location. Objects with a target are wherever their target is,
+// and have no location of their own.
+func (h *Handler) validatePlacement(ctx context.Context, target, location primitive.ObjectID) error {
+	if !target.IsZero() && !location.IsZero() {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Objects with a target cannot have a location, they are wherever their target %s is", target.Hex())}
+	}
+
+	return h.validateLocation(ctx, location)
+}
+
+// validateParent returns a *statusError if the location id, of kind, cannot
+// be inside parent: if kind is invalid, if parent does not exist or is not a
+// larger kind of location, or if id contains locations that are not smaller
+// than kind.
+//
+// id may be primitive.NilObjectID for a location that is not yet created. As
+// parents are always larger than the locations they contain, they cannot form
+// cycles.
+func (h *Handler) validateParent(ctx context.Context, id primitive.ObjectID, kind string, parent primitive.ObjectID) error {
+	if err := types.ValidateLocationKind(kind); err != nil {
+		return &statusError{http.StatusUnprocessableEntity, err.Error()}
+	}
+
+	if !parent.IsZero() {
+		if parent == id {
+			return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Location %s cannot be inside itself", id.Hex())}
+		}
+
+		document, err := h.findLocation(ctx, parent)
+		if err == mongo.ErrNoDocuments {
+			return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Parent %s does not exist", parent.Hex())}
+		}
+		if err != nil {
+			return err
+		}
+
+		parentKind, _ := document["kind"].(string)
+
+		if err := types.ValidateLocationParent(kind, parentKind); err != nil {
+			return &statusError{http.StatusUnprocessableEntity, err.Error()}
+		}
+	}
+
+	if id.IsZero() {
+		return nil
+	}
+
+	children, err := h.Store.ReadAll(ctx, "locations", db.And(bson.D{{Key: "parent", Value: id}}, db.NotTrashed()), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		childKind, _ := (*child)["kind"].(string)
+
+		if err := types.ValidateLocationParent(childKind, kind); err != nil {
+			childID, _ := (*child)["_id"].(primitive.ObjectID)
+			return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Location %s contains %s: %s", id.Hex(), childID.Hex(), err)}
+		}
+	}
+
+	return nil
+}
+
+// updateLocation replaces the values of "location" and "parent" in set, if
+// present, by the ObjectIDs they represent, and validates them along with the
+// other changes of the object id of collection made by set and unset.
+//
+// The kind and parent of locations are validated together, with the values
+// of current, which is read if nil.
+func (h *Handler) updateLocation(ctx context.Context, collection string, id primitive.ObjectID, set, unset bson.D, current bson.M) error {
+	changed := false
+
+	for i, field := range set {
+		switch field.Key {
+		case "location", "parent":
+			value, err := objectIDField(field)
+			if err != nil {
+				return err
+			}
+
+			if field.Key == "location" {
+				if err := h.validateLocation(ctx, value); err != nil {
+					return err
+				}
+			}
+
+			set[i].Value = value
+		}
+
+		changed = changed || field.Key == "kind" || field.Key == "parent"
+	}
+
+	for _, field := range unset {
+		changed = changed || field.Key == "kind" || field.Key == "parent"
+	}
+
+	if collection != "locations" || !changed {
+		return nil
+	}
+
+	if current == nil {
+		var err error
+		if current, err = h.findLocation(ctx, id); err == mongo.ErrNoDocuments {
+			return notFound(id)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	kind, _ := current["kind"].(string)
+	parent, _ := current["parent"].(primitive.ObjectID)
+
+	for _, field := range set {
+		switch field.Key {
+		case "kind":
+			kind, _ = field.Value.(string)
+		case "parent":
+			parent, _ = field.Value.(primitive.ObjectID)
+		}
+	}
+
+	for _, field := range unset {
+		switch field.Key {
+		case "kind":
+			kind = ""
+		case "parent":
+			parent = primitive.NilObjectID
+		}
+	}
+
+	return h.validateParent(ctx, id, kind, parent)
+}
+
+// objectIDField returns the ObjectID represented by the value of field,
+// either an ObjectID or its hex string. nil and "" are the zero ObjectID.
+func objectIDField(field bson.E) (primitive.ObjectID, error) {
+	switch value := field.Value.(type) {
+	case primitive.ObjectID:
+		return value, nil
+	case string:
+		if value == "" {
+			return primitive.NilObjectID, nil
+		}
+
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return id, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid %s '%s': %s", field.Key, value, err)}
+		}
+
+		return id, nil
+	case nil:
+		return primitive.NilObjectID, nil
+	default:
+		return primitive.NilObjectID, &statusError{http.StatusBadRequest, fmt.Sprintf("%s must be an ObjectID string", field.Key)}
+	}
+}
+
+// place adjusts the update data of the object id of collection so that
+// objects with a target have no location: setting a target unsets the
+// location, and setting a location is refused while the object has a target.
+//
+// current is read if nil and needed, and returned along with data.
+func (h *Handler) place(ctx context.Context, collection string, id primitive.ObjectID, data bson.D, current bson.M) (bson.D, bson.M, error) {
+	if _, ok := targetCollections[collection]; !ok {
+		return data, current, nil
+	}
+
+	target, targetChanged := objectIDChange(data, "target")
+	location, locationChanged := objectIDChange(data, "location")
+
+	if targetChanged && !target.IsZero() && !locationChanged {
+		return withUnset(data, "location"), current, nil
+	}
+
+	if !locationChanged || location.IsZero() {
+		return data, current, nil
+	}
+
+	if !targetChanged {
+		if current == nil {
+			var err error
+			if current, err = h.Store.ReadFromID(ctx, collection, id); err == mongo.ErrNoDocuments {
+				return nil, nil, notFound(id)
+			} else if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		target, _ = current["target"].(primitive.ObjectID)
+	}
+
+	if !target.IsZero() {
+		return nil, nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Object %s cannot have a location while it has a target, it is wherever its target %s is. Unset its target first", id.Hex(), target.Hex())}
+	}
+
+	return data, current, nil
+}
+
+// objectIDChange returns the ObjectID set to key by the update data, the zero
+// ObjectID if it unsets it, and whether it changes key at all.
+func objectIDChange(data bson.D, key string) (primitive.ObjectID, bool) {
+	for _, operator := range data {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			if field.Key != key {
+				continue
+			}
+
+			switch operator.Key {
+			case "$set":
+				id, _ := field.Value.(primitive.ObjectID)
+				return id, true
+			case "$unset":
+				return primitive.NilObjectID, true
+			}
+		}
+	}
+
+	return primitive.NilObjectID, false
+}
+
+// withUnset returns the update data, also unsetting key.
+func withUnset(data bson.D, key string) bson.D {
+	for i, operator := range data {
+		if operator.Key != "$unset" {
+			continue
+		}
+
+		if fields, ok := operator.Value.(bson.D); ok {
+			data[i].Value = append(fields, bson.E{Key: key, Value: ""})
+			return data
+		}
+	}
+
+	return append(data, bson.E{Key: "$unset", Value: bson.D{{Key: key, Value: ""}}})
+}
+
+// locationReferrers returns the locations inside the location id, then the
+// objects at it, except those in the trash.
+func (h *Handler) locationReferrers(ctx context.Context, id primitive.ObjectID) ([]types.Referrer, error) {
+	var referrers []types.Referrer
+
+	queries := map[string]bson.D{"locations": {{Key: "parent", Value: id}}}
+	for _, collection := range locatedCollections {
+		queries[collection] = bson.D{{Key: "location", Value: id}}
+	}
+
+	for _, collection := range append([]string{"locations"}, locatedCollections...) {
+		documents, err := h.Store.ReadAll(ctx, collection, db.And(queries[collection], db.NotTrashed()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documents {
+			referrer := types.Referrer{Collection: collection}
+
+			referrer.ID, _ = (*document)["_id"].(primitive.ObjectID)
+			referrer.Name, _ = (*document)["name"].(string)
+
+			referrers = append(referrers, referrer)
+		}
+	}
+
+	return referrers, nil
+}
+
+// deleteLocation moves the location id to the trash, handling the locations
+// and objects it contains according to the "on_referenced" query parameter.
+//
+// Deleting a location never deletes what it contains, so on_referenced=cascade
+// is refused.
+func (h *Handler) deleteLocation(c echo.Context, id primitive.ObjectID) error {
+	ctx := c.Request().Context()
+
+	onReferenced := c.QueryParam("on_referenced")
+
+	switch onReferenced {
+	case "":
+		onReferenced = types.OnReferencedReject
+	case types.OnReferencedReject, types.OnReferencedOrphan:
+	case types.OnReferencedCascade:
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Locations cannot be deleted with on_referenced=%s, as what they contain is not deleted with them. Retry with on_referenced=%s", types.OnReferencedCascade, types.OnReferencedOrphan))
+	default:
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Invalid on_referenced '%s', must be one of { %s | %s }", onReferenced, types.OnReferencedReject, types.OnReferencedOrphan))
+	}
+
+	// Locations already in the trash cannot be deleted again
+	if _, err := h.readRelative(c, "locations", id.Hex()); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	referrers, err := h.locationReferrers(ctx, id)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if len(referrers) > 0 {
+		if onReferenced == types.OnReferencedReject {
+			return errorDetailsJSON(c, http.StatusConflict,
+				fmt.Sprintf("Location %s contains %d objects, retry with on_referenced=%s", id.Hex(), len(referrers), types.OnReferencedOrphan),
+				types.ReferencedDetails{Referrers: referrers},
+			)
+		}
+
+		// Objects in the trash are orphaned too, so that they are not
+		// restored in a deleted location
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "parent", Value: primitive.NilObjectID}}}}
+
+		if _, err := h.Store.UpdateMany(ctx, "locations", bson.D{{Key: "parent", Value: id}}, update); err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		update = bson.D{{Key: "$unset", Value: bson.D{{Key: "location", Value: ""}}}}
+
+		for _, collection := range locatedCollections {
+			if _, err := h.Store.UpdateMany(ctx, collection, bson.D{{Key: "location", Value: id}}, update); err != nil {
+				return internalErrorJSON(c, err)
+			}
+		}
+	}
+
+	result, err := db.Trash(ctx, h.Store, "locations", id, time.Now().UTC())
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	// Deleted meanwhile by another request
+	if result.ModifiedCount == 0 {
+		return statusErrorJSON(c, notFound(id))
+	}
+
+	return c.JSON(http.StatusOK, &mongo.DeleteResult{DeletedCount: result.ModifiedCount})
+}
+
+// HandleV1LocationContents responds with the locations inside a location,
+// recursively, then the kits, assemblies and components at any of them.
+// Objects in the trash are left out.
+func (h *Handler) HandleV1LocationContents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	document, err := h.readRelative(c, "locations", c.Param("location"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	relatives := types.Relatives{Object: node("locations", document), Objects: []types.Node{}}
+
+	byName := &db.ReadOptions{Sort: bson.D{{Key: "name", Value: 1}}}
+
+	// Walk down the locations, a level at a time
+	locations := bson.A{relatives.Object.ID}
+	level := bson.A{relatives.Object.ID}
+	seen := map[primitive.ObjectID]bool{relatives.Object.ID: true}
+
+	for len(level) > 0 {
+		query := db.And(bson.D{{Key: "parent", Value: bson.D{{Key: "$in", Value: level}}}}, db.NotTrashed())
+
+		documents, err := h.Store.ReadAll(ctx, "locations", query, byName)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		level = bson.A{}
+
+		for _, document := range documents {
+			id, _ := (*document)["_id"].(primitive.ObjectID)
+			if seen[id] {
+				continue
+			}
+
+			seen[id] = true
+
+			locations = append(locations, id)
+			level = append(level, id)
+			relatives.Objects = append(relatives.Objects, node("locations", *document))
+		}
+	}
+
+	for _, collection := range locatedCollections {
+		query := db.And(bson.D{{Key: "location", Value: bson.D{{Key: "$in", Value: locations}}}}, db.NotTrashed())
+
+		documents, err := h.Store.ReadAll(ctx, collection, query, byName)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for _, document := range documents {
+			relatives.Objects = append(relatives.Objects, node(collection, *document))
+		}
+	}
+
+	return c.JSON(http.StatusOK, relatives)
+}
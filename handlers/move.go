@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleV1AssemblyMove changes the target of the assembly, with every
+// component it contains, and responds with its types.Tree.
+func (h *Handler) HandleV1AssemblyMove(c echo.Context) error {
+	assemblyID, err := primitive.ObjectIDFromHex(c.Param("assembly"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	var data types.Move
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with a target ObjectID")
+	}
+
+	fields := bson.D{{Key: "target", Value: data.Target}}
+	if data.Target == "" {
+		fields[0].Value = primitive.NilObjectID
+	}
+
+	if err := h.updateTarget(c.Request().Context(), "assemblies", assemblyID, fields); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	if _, err := h.updateFromID(c, "assemblies", assemblyID, bson.D{{Key: "$set", Value: fields}}, nil); err != nil {
+		var e *statusError
+		if errors.As(err, &e) {
+			return statusErrorJSON(c, err)
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	return h.tree(c, "assemblies", assemblyID.Hex())
+}
+
+// HandleV1KitClone copies the kit with its assemblies and components, as
+// described by a types.KitClone, and responds with the types.Tree of the
+// copy.
+func (h *Handler) HandleV1KitClone(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	var data types.KitClone
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with the name, or names, of the clone")
+	}
+
+	kit, err := h.readRelative(c, "kits", kitID.Hex())
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	objects, err := h.descendants(ctx, "kits", kitID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	objects["kits"] = []bson.M{kit}
+
+	// ObjectIDs of the copies, by ObjectID of the original
+	ids := map[primitive.ObjectID]primitive.ObjectID{}
+
+	for _, documents := range objects {
+		for _, document := range documents {
+			id, _ := document["_id"].(primitive.ObjectID)
+			ids[id] = primitive.NewObjectID()
+		}
+	}
+
+	copies := map[string][]interface{}{}
+
+	for collection, documents := range objects {
+		schema, err := h.schema(ctx, collection)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for _, document := range documents {
+			copies[collection] = append(copies[collection], cloneDocument(document, ids, data, schema, h.uniqueTagKeys(collection)))
+		}
+	}
+
+	if data.Name != "" {
+		copies["kits"][0].(bson.M)["name"] = data.Name
+	}
+
+	inserted := []string{}
+
+	// Targets are inserted before the objects targeting them
+	for _, collection := range []string{"kits", "assemblies", "components"} {
+		if len(copies[collection]) == 0 {
+			continue
+		}
+
+		if _, err := h.Store.InsertMany(ctx, collection, copies[collection]); err != nil {
+			// The copies already inserted are removed, such as when a copy
+			// holds a unique value
+			for _, collection := range inserted {
+				copied := bson.A{}
+
+				for _, document := range copies[collection] {
+					copied = append(copied, document.(bson.M)["_id"])
+				}
+
+				if _, undoErr := h.Store.DeleteMany(ctx, collection, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: copied}}}}); undoErr != nil {
+					log.Printf("[%s] The %s cloned from kit %s are left half-inserted: %s", c.Response().Header().Get(echo.HeaderXRequestID), collection, kitID.Hex(), undoErr)
+				}
+			}
+
+			return internalErrorJSON(c, err)
+		}
+
+		inserted = append(inserted, collection)
+	}
+
+	return h.tree(c, "kits", ids[kitID].Hex())
+}
+
+// descendants returns the objects contained in the object id of collection,
+// directly or not, by collection. Objects in the trash are left out.
+func (h *Handler) descendants(ctx context.Context, collection string, id primitive.ObjectID) (map[string][]bson.M, error) {
+	objects := map[string][]bson.M{}
+
+	level := map[primitive.ObjectID]*types.Tree{id: nil}
+	collections := map[primitive.ObjectID]string{id: collection}
+
+	// Objects already walked, in case targets form a cycle
+	seen := map[primitive.ObjectID]bool{id: true}
+
+	for len(level) > 0 {
+		children, err := h.readChildren(ctx, level, collections)
+		if err != nil {
+			return nil, err
+		}
+
+		level = map[primitive.ObjectID]*types.Tree{}
+
+		for _, targetChildren := range children {
+			for _, child := range targetChildren {
+				childID, _ := child.document["_id"].(primitive.ObjectID)
+				if seen[childID] {
+					continue
+				}
+
+				seen[childID] = true
+
+				objects[child.collection] = append(objects[child.collection], child.document)
+
+				level[childID] = nil
+				collections[childID] = child.collection
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// cloneDocument returns a copy of document with the ObjectIDs of ids and the
+// rewrites of clone applied. The version, trash and loan fields are not
+// copied, nor are the unique custom fields of schema, such as serial numbers,
+// nor the tags of uniqueTagKeys left as they are.
+func cloneDocument(document bson.M, ids map[primitive.ObjectID]primitive.ObjectID, clone types.KitClone, schema *types.Schema, uniqueTagKeys []string) bson.M {
+	copied := bson.M{}
+
+	for key, value := range document {
+		copied[key] = value
+	}
+
+	delete(copied, db.VersionField)
+	delete(copied, db.TrashedField)
+
+	id, _ := document["_id"].(primitive.ObjectID)
+	copied["_id"] = ids[id]
+
+	if target, ok := document["target"].(primitive.ObjectID); ok {
+		if cloned, ok := ids[target]; ok {
+			copied["target"] = cloned
+		}
+	}
+
+	if name, ok := document["name"].(string); ok {
+		for _, rewrite := range clone.Names {
+			if rewrite.Old != "" {
+				name = strings.ReplaceAll(name, rewrite.Old, rewrite.New)
+			}
+		}
+
+		copied["name"] = name
+	}
+
+	tags := []string{}
+
+	for _, tag := range documentTags(document) {
+		rewritten := false
+
+		for _, rewrite := range clone.Tags {
+			if tag == rewrite.Old {
+				tag = rewrite.New
+				rewritten = true
+			}
+		}
+
+		if key, _, ok := types.ParseTag(tag); ok && !rewritten && contains(uniqueTagKeys, key) {
+			continue
+		}
+
+		if tag != "" && !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, tag := range clone.AddTags {
+		if tag != "" && !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	copied["tags"] = tags
+
+	if fields, err := schema.ValidateValues(document["fields"]); err == nil {
+		for _, field := range schema.Fields {
+			if field.Unique {
+				delete(fields, field.Name)
+			}
+		}
+
+		copied["fields"] = fields
+	}
+
+	return copied
+}
@@ -0,0 +1,587 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// patchRetries is the number of times a patch is applied again when the
+// object was modified by someone else while it was being patched.
+const patchRetries = 3
+
+func (h *Handler) HandleV1ComponentPatch(c echo.Context) error {
+	return h.patch(c, "components", c.Param("component"), types.Component{})
+}
+
+func (h *Handler) HandleV1AssemblyPatch(c echo.Context) error {
+	return h.patch(c, "assemblies", c.Param("assembly"), types.Assembly{})
+}
+
+func (h *Handler) HandleV1KitPatch(c echo.Context) error {
+	return h.patch(c, "kits", c.Param("kit"), types.Kit{})
+}
+
+func (h *Handler) HandleV1LocationPatch(c echo.Context) error {
+	return h.patch(c, "locations", c.Param("location"), types.Location{})
+}
+
+// patch applies the merge patch or JSON patch in the body of the request to
+// the object idParam of collection, whose fields are those of reference.
+//
+// The patch is applied to the object as read, and the result is only saved if
+// the object was not modified in the meantime, see updateFromID. Without an
+// If-Match header, the patch is applied again to the new version of the
+// object.
+func (h *Handler) patch(c echo.Context, collection, idParam string, reference interface{}) error {
+	ctx := c.Request().Context()
+
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if contentType != types.MergePatchContentType && contentType != types.JSONPatchContentType {
+		return errorJSON(c, http.StatusUnsupportedMediaType, fmt.Sprintf("Content-Type must be %s or %s", types.MergePatchContentType, types.JSONPatchContentType))
+	}
+
+	body, err := readBody(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	_, conditional, err := ifMatch(c)
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		current, err := h.Store.ReadFromID(ctx, collection, id)
+		if err == mongo.ErrNoDocuments || (err == nil && db.IsTrashed(current)) {
+			return errorJSON(c, http.StatusNotFound, "No document with specified ObjectID")
+		}
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		document, err := patchable(current, reference)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		// The document is patched in place
+		original, err := deepCopy(document)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		var patched map[string]interface{}
+
+		if contentType == types.MergePatchContentType {
+			patched, err = applyMergePatch(document, body)
+		} else {
+			patched, err = applyJSONPatch(document, body)
+		}
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		set, unset, err := patchChanges(original.(map[string]interface{}), patched, reference)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if len(set) == 0 && len(unset) == 0 {
+			setETag(c, current)
+			return updateResultJSON(c, &mongo.UpdateResult{MatchedCount: 1})
+		}
+
+		if err := checkQuantityUnchanged(collection, set, unset); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.updateTarget(ctx, collection, id, set); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.updateLocation(ctx, collection, id, set, unset, current); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		if err := h.validateCustomFields(ctx, collection, id, set, unset); err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		var update bson.D
+
+		if len(set) > 0 {
+			update = append(update, bson.E{Key: "$set", Value: set})
+		}
+
+		if len(unset) > 0 {
+			update = append(update, bson.E{Key: "$unset", Value: unset})
+		}
+
+		result, err := h.updateFromID(c, collection, id, update, current)
+		if err != nil {
+			// Modified since it was read, patch the new version unless a
+			// specific version was required
+			if !conditional && attempt < patchRetries && isStatus(err, http.StatusPreconditionFailed) {
+				continue
+			}
+
+			return statusErrorJSON(c, err)
+		}
+
+		return updateResultJSON(c, result)
+	}
+}
+
+// updateResultJSON responds with result, as the update routes do.
+func updateResultJSON(c echo.Context, result *mongo.UpdateResult) error {
+	message, err := json.Marshal(result)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, "Error marshalling result")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": string(message)})
+}
+
+// readBody returns the body of the request, which must be valid JSON.
+func readBody(c echo.Context) ([]byte, error) {
+	var body bytes.Buffer
+
+	if _, err := body.ReadFrom(c.Request().Body); err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("Body must be valid JSON")
+	}
+
+	return body.Bytes(), nil
+}
+
+// isStatus reports whether err is a *statusError of the given status.
+func isStatus(err error, status int) bool {
+	e, ok := err.(*statusError)
+	return ok && e.status == status
+}
+
+// patchable returns the fields of document that can be patched, those of
+// reference, as they are represented in JSON.
+func patchable(document bson.M, reference interface{}) (map[string]interface{}, error) {
+	fields, err := types.GetFields(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+
+	for _, field := range fields {
+		if value, ok := document[field]; ok {
+			values[field] = value
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var patchable map[string]interface{}
+
+	return patchable, json.Unmarshal(data, &patchable)
+}
+
+// applyMergePatch returns document with the merge patch (RFC 7386) applied.
+func applyMergePatch(document map[string]interface{}, patch []byte) (map[string]interface{}, error) {
+	var p interface{}
+
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, &statusError{http.StatusBadRequest, err.Error()}
+	}
+
+	patched, ok := mergePatch(document, p).(map[string]interface{})
+	if !ok {
+		return nil, &statusError{http.StatusUnprocessableEntity, "A merge patch must be an object"}
+	}
+
+	return patched, nil
+}
+
+// mergePatch returns target with patch merged in, where null values remove
+// the fields of target.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+
+	return t
+}
+
+// applyJSONPatch returns document with the operations of the JSON patch
+// (RFC 6902) applied, in order. A failing "test" operation is a conflict.
+func applyJSONPatch(document map[string]interface{}, patch []byte) (map[string]interface{}, error) {
+	var operations []types.PatchOperation
+
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("A JSON patch must be a list of operations: %s", err)}
+	}
+
+	var patched interface{} = document
+
+	for i, operation := range operations {
+		var err error
+
+		if patched, err = applyOperation(patched, operation); err != nil {
+			if e, ok := err.(*statusError); ok {
+				e.message = fmt.Sprintf("Operation %d (%s %s): %s", i, operation.Op, operation.Path, e.message)
+				return nil, e
+			}
+
+			return nil, err
+		}
+	}
+
+	result, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, &statusError{http.StatusUnprocessableEntity, "The patched document must be an object"}
+	}
+
+	return result, nil
+}
+
+// applyOperation returns document with operation applied.
+func applyOperation(document interface{}, operation types.PatchOperation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add":
+		return pointerAdd(document, path, operation.Value)
+	case "remove":
+		return pointerRemove(document, path)
+	case "replace":
+		if _, err := pointerGet(document, path); err != nil {
+			return nil, err
+		}
+
+		if document, err = pointerRemove(document, path); err != nil {
+			return nil, err
+		}
+
+		return pointerAdd(document, path, operation.Value)
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := pointerGet(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "move" {
+			if document, err = pointerRemove(document, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+
+		return pointerAdd(document, path, value)
+	case "test":
+		value, err := pointerGet(document, path)
+		if err != nil {
+			return nil, err
+		}
+
+		expected, err := deepCopy(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(value, expected) {
+			return nil, &statusError{http.StatusConflict, "Test failed, the value is not the expected one"}
+		}
+
+		return document, nil
+	}
+
+	return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Unknown op '%s', must be one of { add | remove | replace | move | copy | test }", operation.Op)}
+}
+
+// parsePointer returns the reference tokens of a JSON Pointer (RFC 6901).
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Invalid path '%s', must start with '/'", pointer)}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex returns the index represented by token in an array of the given
+// length, which may be equal to length when adding.
+func arrayIndex(token string, length int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return length, nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > length || (i == length && !adding) || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Invalid array index '%s'", token)}
+	}
+
+	return i, nil
+}
+
+// pointerGet returns the value at path in document.
+func pointerGet(document interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := document.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+			}
+
+			document = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+
+			document = node[i]
+		default:
+			return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+		}
+	}
+
+	return document, nil
+}
+
+// pointerAdd returns document with value added at path, replacing the value
+// of an object member or inserting it in an array.
+func pointerAdd(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+
+	switch node := document.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+		}
+
+		child, err := pointerAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), len(path) == 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(path) == 1 {
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+
+		child, err := pointerAdd(node[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		node[i] = child
+		return node, nil
+	}
+
+	return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+}
+
+// pointerRemove returns document without the value at path.
+func pointerRemove(document interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, &statusError{http.StatusUnprocessableEntity, "Cannot remove the whole document"}
+	}
+
+	token := path[0]
+
+	switch node := document.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+		}
+
+		if len(path) == 1 {
+			delete(node, token)
+			return node, nil
+		}
+
+		child, err := pointerRemove(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(path) == 1 {
+			return append(node[:i], node[i+1:]...), nil
+		}
+
+		child, err := pointerRemove(node[i], path[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		node[i] = child
+		return node, nil
+	}
+
+	return nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("No value at '%s'", token)}
+}
+
+// deepCopy returns a copy of a JSON value, with numbers as float64 like the
+// values decoded from a document.
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied interface{}
+
+	return copied, json.Unmarshal(data, &copied)
+}
+
+// patchChanges validates patched against the fields and types of reference,
+// and returns the fields to set and to unset to turn document into patched.
+//
+// Removed fields are unset, except "target" which is cleared, and "name"
+// which cannot be removed. Fields stored only when they have a value, such as
+// "fields", are unset as well when patched to their zero value.
+func patchChanges(document, patched map[string]interface{}, reference interface{}) (set, unset bson.D, err error) {
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Decode in a new value of the type of reference, refusing unknown
+	// fields and values of the wrong type
+	typed := reflect.New(reflect.TypeOf(reference)).Interface()
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(typed); err != nil {
+		return nil, nil, &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Invalid patched object: %s", err)}
+	}
+
+	if name, _ := patched["name"].(string); name == "" {
+		return nil, nil, &statusError{http.StatusUnprocessableEntity, "name cannot be empty"}
+	}
+
+	marshalled, err := bson.Marshal(typed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Values of patched as stored, without the fields left out when empty
+	var values bson.M
+	if err := bson.Unmarshal(marshalled, &values); err != nil {
+		return nil, nil, err
+	}
+
+	fields, err := types.GetFields(reference)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		oldValue, stored := document[field]
+		newValue, present := patched[field]
+
+		if stored == present && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		value, valued := values[field]
+
+		switch {
+		case present && valued:
+			set = append(set, bson.E{Key: field, Value: value})
+		case field == "target":
+			set = append(set, bson.E{Key: "target", Value: primitive.NilObjectID})
+		case stored:
+			unset = append(unset, bson.E{Key: field, Value: ""})
+		}
+	}
+
+	return set, unset, nil
+}
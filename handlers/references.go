@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// referrerCollections are the collections whose objects have a target.
+var referrerCollections = []string{"components", "assemblies"}
+
+// referrers returns the objects whose target is id, except those in the
+// trash.
+func (h *Handler) referrers(ctx context.Context, id primitive.ObjectID) ([]types.Referrer, error) {
+	var referrers []types.Referrer
+
+	for _, collection := range referrerCollections {
+		documents, err := h.Store.ReadAll(ctx, collection, db.And(bson.D{{Key: "target", Value: id}}, db.NotTrashed()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documents {
+			referrer := types.Referrer{Collection: collection}
+
+			referrer.ID, _ = (*document)["_id"].(primitive.ObjectID)
+			referrer.Name, _ = (*document)["name"].(string)
+
+			referrers = append(referrers, referrer)
+		}
+	}
+
+	return referrers, nil
+}
+
+// delete moves the object id of collection to the trash, handling the
+// objects whose target is id according to the "on_referenced" query
+// parameter.
+func (h *Handler) delete(c echo.Context, collection string, id primitive.ObjectID) error {
+	ctx := c.Request().Context()
+
+	// Every object deleted by this request shares the same time of deletion,
+	// so that a cascade can be restored at once
+	now := time.Now().UTC()
+
+	onReferenced := c.QueryParam("on_referenced")
+
+	switch onReferenced {
+	case "":
+		onReferenced = types.OnReferencedReject
+	case types.OnReferencedReject, types.OnReferencedOrphan, types.OnReferencedCascade:
+	default:
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Invalid on_referenced '%s', must be one of { %s | %s | %s }", onReferenced, types.OnReferencedReject, types.OnReferencedOrphan, types.OnReferencedCascade))
+	}
+
+	// Objects already in the trash cannot be deleted again
+	document, err := h.readRelative(c, collection, id.Hex())
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	// Lent kits, and what they contain, stay until they are checked in
+	if !forced(c) {
+		if err := h.checkNotLentKit(ctx, collection, document); err != nil {
+			return statusErrorJSON(c, err)
+		}
+	}
+
+	referrers, err := h.referrers(ctx, id)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if len(referrers) > 0 {
+		switch onReferenced {
+		case types.OnReferencedReject:
+			return errorDetailsJSON(c, http.StatusConflict,
+				fmt.Sprintf("Object %s is the target of %d objects, retry with on_referenced=%s or on_referenced=%s", id.Hex(), len(referrers), types.OnReferencedOrphan, types.OnReferencedCascade),
+				types.ReferencedDetails{Referrers: referrers},
+			)
+		case types.OnReferencedOrphan:
+			update := bson.D{
+				primitive.E{
+					Key: "$set", Value: bson.D{
+						bson.E{Key: "target", Value: primitive.NilObjectID}},
+				},
+			}
+
+			for _, referrerCollection := range referrerCollections {
+				_, err := h.Store.UpdateMany(ctx, referrerCollection, bson.D{{Key: "target", Value: id}}, update)
+				if err != nil {
+					return internalErrorJSON(c, err)
+				}
+			}
+		case types.OnReferencedCascade:
+			result, err := h.deleteSubtree(ctx, collection, id, now, map[primitive.ObjectID]bool{})
+			if err != nil {
+				return internalErrorJSON(c, err)
+			}
+
+			return c.JSON(http.StatusOK, result)
+		}
+	}
+
+	result, err := db.Trash(ctx, h.Store, collection, id, now)
+	if err != nil {
+		// other
+		return internalErrorJSON(c, err)
+	}
+
+	// Deleted meanwhile by another request
+	if result.ModifiedCount == 0 {
+		return statusErrorJSON(c, notFound(id))
+	}
+
+	return c.JSON(http.StatusOK, &mongo.DeleteResult{DeletedCount: result.ModifiedCount})
+}
+
+// deleteSubtree moves the object id of collection to the trash, along with
+// every object that targets it, directly or through other objects.
+//
+// visited holds the objects already deleted, so that cycles of targets do not
+// recurse forever.
+func (h *Handler) deleteSubtree(ctx context.Context, collection string, id primitive.ObjectID, at time.Time, visited map[primitive.ObjectID]bool) (*mongo.DeleteResult, error) {
+	total := &mongo.DeleteResult{}
+
+	if visited[id] {
+		return total, nil
+	}
+
+	visited[id] = true
+
+	referrers, err := h.referrers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, referrer := range referrers {
+		result, err := h.deleteSubtree(ctx, referrer.Collection, referrer.ID, at, visited)
+		if err != nil {
+			return nil, err
+		}
+
+		total.DeletedCount += result.DeletedCount
+	}
+
+	result, err := db.Trash(ctx, h.Store, collection, id, at)
+	if err != nil {
+		return nil, err
+	}
+
+	total.DeletedCount += result.ModifiedCount
+
+	return total, nil
+}
+
+// targetCollections lists, for every collection whose objects have a target,
+// the collections in which that target may be.
+var targetCollections = map[string][]string{
+	"components": {"assemblies", "kits"},
+	"assemblies": {"kits"},
+}
+
+// findObject returns the collection in which the object id is, and the
+// object, or mongo.ErrNoDocuments if it is in no collection or in the trash.
+func (h *Handler) findObject(ctx context.Context, id primitive.ObjectID) (string, bson.M, error) {
+	for _, collection := range []string{"components", "assemblies", "kits"} {
+		document, err := h.Store.ReadFromID(ctx, collection, id)
+		if err == nil {
+			if db.IsTrashed(document) {
+				return "", nil, mongo.ErrNoDocuments
+			}
+
+			return collection, document, nil
+		}
+
+		if err != mongo.ErrNoDocuments {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, mongo.ErrNoDocuments
+}
+
+// validateTarget returns a *statusError if the object id of collection may
+// not have target as its target: if target does not exist, is not in one of
+// the targetCollections of collection, is the object itself, or would create
+// a cycle of targets.
+//
+// id may be primitive.NilObjectID for an object that is not yet created. A
+// zero target, which unsets the target, is always valid.
+func (h *Handler) validateTarget(ctx context.Context, collection string, id, target primitive.ObjectID) error {
+	if target.IsZero() {
+		return nil
+	}
+
+	if target == id {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Object %s cannot target itself", id.Hex())}
+	}
+
+	allowed := targetCollections[collection]
+	if len(allowed) == 0 {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Objects in %s cannot have a target", collection)}
+	}
+
+	targetCollection, document, err := h.findObject(ctx, target)
+	if err == mongo.ErrNoDocuments {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Target %s does not exist", target.Hex())}
+	}
+	if err != nil {
+		return err
+	}
+
+	if !contains(allowed, targetCollection) {
+		return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Objects in %s can only target objects in %v, but target %s is in %s", collection, allowed, target.Hex(), targetCollection)}
+	}
+
+	// Walk up the targets of the target, which must never lead back to id
+	visited := map[primitive.ObjectID]bool{target: true}
+
+	for {
+		next, _ := document["target"].(primitive.ObjectID)
+		if next.IsZero() || visited[next] {
+			return nil
+		}
+
+		if next == id {
+			return &statusError{http.StatusUnprocessableEntity, fmt.Sprintf("Target %s would create a cycle, as it is itself contained in %s", target.Hex(), id.Hex())}
+		}
+
+		visited[next] = true
+
+		_, document, err = h.findObject(ctx, next)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// updateTarget replaces the value of "target" in fields, if present, by the
+// ObjectID it represents, and validates it as the target of the object id of
+// collection.
+func (h *Handler) updateTarget(ctx context.Context, collection string, id primitive.ObjectID, fields bson.D) error {
+	for i, field := range fields {
+		if field.Key != "target" {
+			continue
+		}
+
+		var target primitive.ObjectID
+
+		switch value := field.Value.(type) {
+		case primitive.ObjectID:
+			target = value
+		case string:
+			var err error
+			if target, err = primitive.ObjectIDFromHex(value); err != nil {
+				return &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid target '%s': %s", value, err)}
+			}
+		case nil:
+		default:
+			return &statusError{http.StatusBadRequest, "target must be an ObjectID string"}
+		}
+
+		if err := h.validateTarget(ctx, collection, id, target); err != nil {
+			return err
+		}
+
+		fields[i].Value = target
+	}
+
+	return nil
+}
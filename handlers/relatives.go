@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (h *Handler) HandleV1ComponentChildren(c echo.Context) error {
+	return h.children(c, "components", c.Param("component"))
+}
+
+func (h *Handler) HandleV1AssemblyChildren(c echo.Context) error {
+	return h.children(c, "assemblies", c.Param("assembly"))
+}
+
+func (h *Handler) HandleV1KitChildren(c echo.Context) error {
+	return h.children(c, "kits", c.Param("kit"))
+}
+
+func (h *Handler) HandleV1ComponentAncestors(c echo.Context) error {
+	return h.ancestors(c, "components", c.Param("component"))
+}
+
+func (h *Handler) HandleV1AssemblyAncestors(c echo.Context) error {
+	return h.ancestors(c, "assemblies", c.Param("assembly"))
+}
+
+func (h *Handler) HandleV1KitAncestors(c echo.Context) error {
+	return h.ancestors(c, "kits", c.Param("kit"))
+}
+
+// readRelative returns the object idParam of collection, for the routes
+// responding with its types.Relatives. A *statusError is returned when the
+// id is invalid, or the object is missing or in the trash.
+func (h *Handler) readRelative(c echo.Context, collection, idParam string) (bson.M, error) {
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return nil, &statusError{http.StatusBadRequest, err.Error()}
+	}
+
+	document, err := h.Store.ReadFromID(c.Request().Context(), collection, id)
+	if err == mongo.ErrNoDocuments || (err == nil && db.IsTrashed(document)) {
+		return nil, notFound(id)
+	}
+
+	return document, err
+}
+
+// children responds with the objects whose target is the object idParam of
+// collection, assemblies first, except those in the trash.
+func (h *Handler) children(c echo.Context, collection, idParam string) error {
+	document, err := h.readRelative(c, collection, idParam)
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	relatives := types.Relatives{Object: node(collection, document), Objects: []types.Node{}}
+
+	level := map[primitive.ObjectID]*types.Tree{relatives.Object.ID: nil}
+	collections := map[primitive.ObjectID]string{relatives.Object.ID: collection}
+
+	children, err := h.readChildren(c.Request().Context(), level, collections)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	for _, child := range children[relatives.Object.ID] {
+		relatives.Objects = append(relatives.Objects, node(child.collection, child.document))
+	}
+
+	return c.JSON(http.StatusOK, relatives)
+}
+
+// ancestors responds with the targets of the object idParam of collection,
+// from its own target up to the kit containing it. Targets that are missing
+// or in the trash end the walk.
+func (h *Handler) ancestors(c echo.Context, collection, idParam string) error {
+	ctx := c.Request().Context()
+
+	document, err := h.readRelative(c, collection, idParam)
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	relatives := types.Relatives{Object: node(collection, document), Objects: []types.Node{}}
+
+	// Objects already walked, in case targets form a cycle
+	visited := map[primitive.ObjectID]bool{relatives.Object.ID: true}
+
+	for {
+		target, _ := document["target"].(primitive.ObjectID)
+		if target.IsZero() || visited[target] {
+			break
+		}
+
+		visited[target] = true
+
+		collection, document, err = h.findObject(ctx, target)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		relatives.Objects = append(relatives.Objects, node(collection, document))
+	}
+
+	return c.JSON(http.StatusOK, relatives)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleV1KitReserve books the kit, as described by a types.Reserve, and
+// responds with the recorded types.Reservation. Reserving a kit at a time it
+// is already reserved or lent is a conflict, detailed by
+// types.ReservationConflicts.
+func (h *Handler) HandleV1KitReserve(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var data types.Reserve
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with a holder, a start and an end time, and an optional note")
+	}
+
+	data.Holder = strings.TrimSpace(data.Holder)
+
+	if data.Holder == "" {
+		return errorJSON(c, http.StatusUnprocessableEntity, "holder cannot be empty")
+	}
+
+	if !data.End.After(data.Start) {
+		return errorJSON(c, http.StatusUnprocessableEntity, "end must be after start")
+	}
+
+	if !data.End.After(time.Now()) {
+		return errorJSON(c, http.StatusUnprocessableEntity, "end must be a time in the future")
+	}
+
+	for attempt := 0; ; attempt++ {
+		kit, err := h.readRelative(c, "kits", c.Param("kit"))
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		kitID, _ := kit["_id"].(primitive.ObjectID)
+
+		reserved, err := db.Reserved(ctx, h.Store, kitID)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		conflicts, err := h.bookings(ctx, kitID, data.Start, data.End)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		if len(conflicts.Reservations) > 0 || len(conflicts.Loans) > 0 {
+			return errorDetailsJSON(c, http.StatusConflict,
+				fmt.Sprintf("Kit %s is already booked between %s and %s", kitID.Hex(), data.Start.Format(time.RFC3339), data.End.Format(time.RFC3339)),
+				conflicts,
+			)
+		}
+
+		reservation := types.Reservation{
+			ID:     primitive.NewObjectID(),
+			Kit:    kitID,
+			Holder: data.Holder,
+			Start:  data.Start.UTC(),
+			End:    data.End.UTC(),
+			Note:   data.Note,
+		}
+
+		reservation.Name, _ = kit["name"].(string)
+
+		recorded, err := db.RecordReservation(ctx, h.Store, reservation)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		// Another reservation of the kit counted since its bookings were
+		// checked may overlap this one, which is then checked again
+		counted, err := db.CountReservation(ctx, h.Store, kitID, reserved)
+		if err == nil && counted {
+			return c.JSON(http.StatusOK, recorded)
+		}
+
+		if _, undoErr := h.Store.DeleteFromID(ctx, db.ReservationCollection, reservation.ID); undoErr != nil {
+			return internalErrorJSON(c, undoErr)
+		}
+
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		if attempt >= patchRetries {
+			return errorJSON(c, http.StatusConflict, fmt.Sprintf("Kit %s is being reserved by other requests, retry later", kitID.Hex()))
+		}
+	}
+}
+
+// HandleV1KitReservations responds with the reservations of the kit selected
+// by the types.ReservationFilter query parameters, from the earliest start.
+func (h *Handler) HandleV1KitReservations(c echo.Context) error {
+	kitID, err := primitive.ObjectIDFromHex(c.Param("kit"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	query, err := reservationQuery(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	query = db.And(query, bson.D{{Key: "kit", Value: kitID}})
+
+	reservations, err := db.ReadReservations(c.Request().Context(), h.Store, query)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, types.Reservations{Reservations: reservations})
+}
+
+// HandleV1Reservations responds with the reservations selected by the
+// types.ReservationFilter query parameters, from the earliest start.
+func (h *Handler) HandleV1Reservations(c echo.Context) error {
+	query, err := reservationQuery(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	reservations, err := db.ReadReservations(c.Request().Context(), h.Store, query)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, types.Reservations{Reservations: reservations})
+}
+
+// HandleV1ReservationsCalendar responds with the reservations selected by
+// the types.ReservationFilter query parameters as an iCalendar feed, to be
+// subscribed to by calendar clients.
+func (h *Handler) HandleV1ReservationsCalendar(c echo.Context) error {
+	query, err := reservationQuery(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	reservations, err := db.ReadReservations(c.Request().Context(), h.Store, query)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	feed := (&types.Reservations{Reservations: reservations}).ICalendar(c.Request().Host)
+
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+}
+
+// HandleV1ReservationDelete cancels the reservation.
+func (h *Handler) HandleV1ReservationDelete(c echo.Context) error {
+	reservationID, err := primitive.ObjectIDFromHex(c.Param("reservation"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	result, err := h.Store.DeleteFromID(c.Request().Context(), db.ReservationCollection, reservationID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if result.DeletedCount == 0 {
+		return statusErrorJSON(c, notFound(reservationID))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// HandleV1Availability responds with the kits selected by the types.Filter
+// query parameters that are neither reserved nor lent at any time between
+// the "start" and "end" query parameters, by name.
+func (h *Handler) HandleV1Availability(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	start, err := queryTime(c, "start")
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	end, err := queryTime(c, "end")
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	if start.IsZero() || end.IsZero() {
+		return errorJSON(c, http.StatusBadRequest, "start and end are required")
+	}
+
+	if !end.After(start) {
+		return errorJSON(c, http.StatusBadRequest, "end must be after start")
+	}
+
+	schema, err := h.schema(ctx, "kits")
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	filter, err := filterQuery(c, schema)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	kits, err := h.Store.ReadAll(ctx, "kits", db.And(filter, db.NotTrashed()), &db.ReadOptions{
+		Sort: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	busy, err := h.bookedKits(ctx, start, end)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	free := []*bson.M{}
+
+	for _, kit := range kits {
+		if id, _ := (*kit)["_id"].(primitive.ObjectID); !busy[id] {
+			free = append(free, kit)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"start": start,
+		"end":   end,
+		"kits":  free,
+	})
+}
+
+// bookings returns the reservations and active loans of the kit at some time
+// between start and end.
+func (h *Handler) bookings(ctx context.Context, kit primitive.ObjectID, start, end time.Time) (*types.ReservationConflicts, error) {
+	reservations, err := db.ReadReservations(ctx, h.Store, db.And(bson.D{{Key: "kit", Value: kit}}, db.Overlapping(start, end)))
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := db.ReadLoans(ctx, h.Store, bson.D{
+		{Key: "collection", Value: "kits"},
+		{Key: "object", Value: kit},
+		{Key: "returned_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := types.ReservationConflicts{Reservations: reservations, Loans: []types.Loan{}}
+	now := time.Now()
+
+	for _, loan := range loans {
+		if loan.Overlaps(start, end, now) {
+			conflicts.Loans = append(conflicts.Loans, loan)
+		}
+	}
+
+	return &conflicts, nil
+}
+
+// bookedKits returns the ObjectIDs of the kits reserved or lent at some time
+// between start and end.
+func (h *Handler) bookedKits(ctx context.Context, start, end time.Time) (map[primitive.ObjectID]bool, error) {
+	booked := map[primitive.ObjectID]bool{}
+
+	reservations, err := db.ReadReservations(ctx, h.Store, db.Overlapping(start, end))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range reservations {
+		booked[reservation.Kit] = true
+	}
+
+	loans, err := db.ReadLoans(ctx, h.Store, bson.D{
+		{Key: "collection", Value: "kits"},
+		{Key: "returned_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	for _, loan := range loans {
+		if loan.Overlaps(start, end, now) {
+			booked[loan.Object] = true
+		}
+	}
+
+	return booked, nil
+}
+
+// reservationQuery returns the query described by the
+// types.ReservationFilter query parameters of the request.
+func reservationQuery(c echo.Context) (bson.D, error) {
+	var filter types.ReservationFilter
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		return nil, err
+	}
+
+	query := bson.D{}
+
+	if filter.Kit != "" {
+		kit, err := primitive.ObjectIDFromHex(filter.Kit)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid kit '%s': %w", filter.Kit, err)
+		}
+
+		query = append(query, bson.E{Key: "kit", Value: kit})
+	}
+
+	if filter.Holder != "" {
+		query = append(query, bson.E{Key: "holder", Value: filter.Holder})
+	}
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, err
+	}
+
+	if !from.IsZero() {
+		query = append(query, bson.E{Key: "end", Value: bson.D{{Key: "$gt", Value: from}}})
+	}
+
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	if !to.IsZero() {
+		query = append(query, bson.E{Key: "start", Value: bson.D{{Key: "$lt", Value: to}}})
+	}
+
+	return query, nil
+}
+
+// queryTime returns the RFC 3339 time of the query parameter name, or the
+// zero time if it is not set.
+func queryTime(c echo.Context, name string) (time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid %s '%s', must be RFC 3339 (e.g. 2026-11-01T09:00:00Z)", name, value)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleV1SchemaRead responds with the types.Schema of the kind.
+func (h *Handler) HandleV1SchemaRead(c echo.Context) error {
+	collection, err := schemaCollection(c.Param("kind"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	schema, err := h.schema(c.Request().Context(), collection)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schema)
+}
+
+// HandleV1SchemaUpdate replaces the schema of the kind with the types.Schema
+// of the body, and responds with it. Changing a schema that existing objects
+// of the kind do not match is a conflict, detailed by types.SchemaConflicts.
+func (h *Handler) HandleV1SchemaUpdate(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	collection, err := schemaCollection(c.Param("kind"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	var schema types.Schema
+
+	if err := c.Bind(&schema); err != nil {
+		return bindErrorJSON(c, err, "a JSON schema with a list of fields")
+	}
+
+	schema.Kind = collectionKinds[collection]
+
+	if schema.Fields == nil {
+		schema.Fields = []types.Field{}
+	}
+
+	if err := schema.Validate(); err != nil {
+		return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	documents, err := h.Store.ReadAll(ctx, collection, db.NotTrashed(), &db.ReadOptions{
+		Sort: bson.D{{Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	conflicts := types.SchemaConflicts{Objects: []types.SchemaConflict{}}
+
+	// Objects already holding a value of a unique field, by value
+	holders := map[string]map[interface{}]primitive.ObjectID{}
+
+	for _, document := range documents {
+		id, _ := (*document)["_id"].(primitive.ObjectID)
+		name, _ := (*document)["name"].(string)
+
+		values, err := schema.ValidateValues((*document)["fields"])
+		if err != nil {
+			conflicts.Objects = append(conflicts.Objects, types.SchemaConflict{ID: id, Name: name, Error: err.Error()})
+			continue
+		}
+
+		for _, field := range schema.Fields {
+			value, ok := values[field.Name]
+			if !field.Unique || !ok {
+				continue
+			}
+
+			if holders[field.Name] == nil {
+				holders[field.Name] = map[interface{}]primitive.ObjectID{}
+			}
+
+			if holder, ok := holders[field.Name][value]; ok {
+				conflicts.Objects = append(conflicts.Objects, types.SchemaConflict{ID: id, Name: name, Error: fmt.Sprintf("Field '%s' has the value %v of %s", field.Name, value, holder.Hex())})
+				continue
+			}
+
+			holders[field.Name][value] = id
+		}
+	}
+
+	if len(conflicts.Objects) > 0 {
+		return errorDetailsJSON(c, http.StatusConflict, fmt.Sprintf("%d objects do not match the schema, change them first", len(conflicts.Objects)), conflicts)
+	}
+
+	previous, err := h.schema(ctx, collection)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	// Objects written since they were checked may share a unique value, in
+	// which case the store refuses the schema
+	if err := db.EnsureUniqueFields(ctx, h.Store, previous, &schema); err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if err := db.WriteSchema(ctx, h.Store, schema); err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if err := db.DropUniqueFields(ctx, h.Store, previous, &schema); err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schema)
+}
+
+// schemaCollection returns the collection of the objects of kind, or a
+// *statusError if it is not a kind with a schema.
+func schemaCollection(kind string) (string, error) {
+	collection, ok := kindCollections[kind]
+	if !ok {
+		return "", &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid kind '%s', must be one of { component | assembly | kit | location }", kind)}
+	}
+
+	return collection, nil
+}
+
+// schema returns the schema of the objects of collection.
+func (h *Handler) schema(ctx context.Context, collection string) (*types.Schema, error) {
+	return db.ReadSchema(ctx, h.Store, collectionKinds[collection])
+}
+
+// validateNewFields returns the custom fields values of a new object of
+// collection, converted by schema. Each unique value must not be used by
+// another object, nor be in seen, which holds the values of the other new
+// objects of the request.
+func (h *Handler) validateNewFields(ctx context.Context, collection string, schema *types.Schema, values map[string]interface{}, seen map[string]bool) (map[string]interface{}, error) {
+	validated, err := schema.ValidateValues(values)
+	if err != nil {
+		return nil, &statusError{http.StatusUnprocessableEntity, err.Error()}
+	}
+
+	if err := h.checkUnique(ctx, collection, schema, primitive.NilObjectID, validated); err != nil {
+		return nil, err
+	}
+
+	for _, field := range schema.Fields {
+		value, ok := validated[field.Name]
+		if !field.Unique || !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s=%v", field.Name, value)
+		if seen[key] {
+			return nil, &statusError{http.StatusConflict, fmt.Sprintf("Field '%s' has the value %v more than once", field.Name, value)}
+		}
+
+		seen[key] = true
+	}
+
+	return validated, nil
+}
+
+// validateCustomFields validates the custom fields set, or unset, by an
+// update of the object id of collection against the schema of its kind. The
+// "fields" of set are replaced by their converted values.
+func (h *Handler) validateCustomFields(ctx context.Context, collection string, id primitive.ObjectID, set, unset bson.D) error {
+	index := -1
+
+	for i, element := range set {
+		if element.Key == "fields" {
+			index = i
+		}
+	}
+
+	unsetting := false
+
+	for _, element := range unset {
+		unsetting = unsetting || element.Key == "fields"
+	}
+
+	if index < 0 && !unsetting {
+		return nil
+	}
+
+	schema, err := h.schema(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	var values interface{}
+	if index >= 0 {
+		values = set[index].Value
+	}
+
+	// Required fields cannot be unset either
+	validated, err := schema.ValidateValues(values)
+	if err != nil {
+		return &statusError{http.StatusUnprocessableEntity, err.Error()}
+	}
+
+	if err := h.checkUnique(ctx, collection, schema, id, validated); err != nil {
+		return err
+	}
+
+	if index >= 0 {
+		set[index].Value = validated
+	}
+
+	return nil
+}
+
+// checkUnique returns a *detailsError if a unique field of values has the
+// value of an object of collection other than id.
+func (h *Handler) checkUnique(ctx context.Context, collection string, schema *types.Schema, id primitive.ObjectID, values map[string]interface{}) error {
+	for _, field := range schema.Fields {
+		value, ok := values[field.Name]
+		if !field.Unique || !ok {
+			continue
+		}
+
+		query := db.And(
+			bson.D{{Key: "fields." + field.Name, Value: value}},
+			bson.D{{Key: "_id", Value: bson.D{{Key: "$ne", Value: id}}}},
+			db.NotTrashed(),
+		)
+
+		documents, err := h.Store.ReadAll(ctx, collection, query, &db.ReadOptions{Limit: 1})
+		if err != nil {
+			return err
+		}
+
+		if len(documents) == 0 {
+			continue
+		}
+
+		holder, _ := (*documents[0])["_id"].(primitive.ObjectID)
+
+		return &detailsError{
+			statusError{http.StatusConflict, fmt.Sprintf("Field '%s' has the value %v of %s %s", field.Name, value, collectionKinds[collection], holder.Hex())},
+			types.DuplicateDetails{Field: field.Name, Value: value, ID: holder},
+		}
+	}
+
+	return nil
+}
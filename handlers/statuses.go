@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleV1Statuses responds with the allowed statuses and the transitions
+// between them, which is empty when any status is allowed.
+func (h *Handler) HandleV1Statuses(c echo.Context) error {
+	if !h.Statuses.Enforced() {
+		return c.JSON(http.StatusOK, types.Statuses{Statuses: []types.Status{}})
+	}
+
+	return c.JSON(http.StatusOK, h.Statuses)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stockOf returns the types.Stock of the component document, and whether it
+// has a quantity.
+func stockOf(document bson.M) (*types.Stock, bool, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var stock types.Stock
+	if err := bson.Unmarshal(raw, &stock); err != nil {
+		return nil, false, err
+	}
+
+	return &stock, document["quantity"] != nil, nil
+}
+
+// checkQuantityUnchanged returns a *statusError if an update of an object of
+// collection sets, or unsets, the quantity of a component, which only changes
+// through the movements of its stock.
+func checkQuantityUnchanged(collection string, set, unset bson.D) error {
+	if collection != "components" {
+		return nil
+	}
+
+	for _, element := range append(append(bson.D{}, set...), unset...) {
+		if element.Key == "quantity" {
+			return &statusError{http.StatusUnprocessableEntity, "The quantity of a component only changes through its stock, see 'POST /v1/component/:component/stock'"}
+		}
+	}
+
+	return nil
+}
+
+// HandleV1ComponentStock responds with the quantity of the component and
+// the movements of its stock.
+func (h *Handler) HandleV1ComponentStock(c echo.Context) error {
+	document, err := h.readRelative(c, "components", c.Param("component"))
+	if err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	stock, tracked, err := stockOf(document)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	if !tracked {
+		return errorJSON(c, http.StatusNotFound, fmt.Sprintf("Component %s has no quantity", stock.ID.Hex()))
+	}
+
+	stock.Movements, err = db.ReadStockMovements(c.Request().Context(), h.Store, stock.ID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, stock)
+}
+
+// HandleV1ComponentStockChange adds to, or takes from, the quantity of the
+// component, as described by a types.StockChange, and records the movement.
+// Components without a quantity start from 0 when added to.
+//
+// Taking more than the quantity is a conflict, the stock never goes under 0.
+func (h *Handler) HandleV1ComponentStockChange(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	componentID, err := primitive.ObjectIDFromHex(c.Param("component"))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	var data types.StockChange
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with a quantity to add or take, and an optional reason")
+	}
+
+	if data.Add < 0 || data.Take < 0 || (data.Add == 0) == (data.Take == 0) {
+		return errorJSON(c, http.StatusUnprocessableEntity, "Exactly one of 'add' or 'take' must be a positive quantity")
+	}
+
+	delta := data.Add
+	if data.Take > 0 {
+		delta = -data.Take
+	}
+
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "quantity", Value: delta}}}}
+
+	for attempt := 0; ; attempt++ {
+		document, err := h.readRelative(c, "components", componentID.Hex())
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		stock, tracked, err := stockOf(document)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		if data.Take > 0 && !tracked {
+			return errorJSON(c, http.StatusConflict, fmt.Sprintf("Component %s has no quantity, add to it first", componentID.Hex()))
+		}
+
+		if data.Take > stock.Quantity {
+			return errorDetailsJSON(c, http.StatusConflict, fmt.Sprintf("Not enough stock of component %s to take %s, only %s left", componentID.Hex(), strconv.FormatFloat(data.Take, 'f', -1, 64), strconv.FormatFloat(stock.Quantity, 'f', -1, 64)), stock)
+		}
+
+		// The quantity only changes from the one read, so that the movement
+		// records the quantity resulting from this change alone
+		result, err := db.UpdateIfVersion(ctx, h.Store, "components", componentID, db.Version(document), update)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		if result.MatchedCount == 0 {
+			if attempt < patchRetries {
+				continue
+			}
+
+			return errorJSON(c, http.StatusConflict, fmt.Sprintf("Component %s is being modified by other requests, retry later", componentID.Hex()))
+		}
+
+		stock.Quantity += delta
+
+		movement, err := db.RecordStockMovement(ctx, h.Store, componentID, delta, stock.Quantity, data.Reason)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		stock.Movements = []types.StockMovement{*movement}
+
+		document[db.VersionField] = db.Version(document) + 1
+		setETag(c, document)
+
+		return c.JSON(http.StatusOK, stock)
+	}
+}
+
+// HandleV1StockLow responds with the components whose quantity is at or
+// under their low stock threshold, by name.
+func (h *Handler) HandleV1StockLow(c echo.Context) error {
+	query := db.And(bson.D{{Key: "low_stock", Value: bson.D{{Key: "$exists", Value: true}}}}, db.NotTrashed())
+
+	documents, err := h.Store.ReadAll(c.Request().Context(), "components", query, nil)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	levels := types.StockLevels{Stock: []types.Stock{}}
+
+	for _, document := range documents {
+		stock, _, err := stockOf(*document)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		if stock.Low() {
+			levels.Stock = append(levels.Stock, *stock)
+		}
+	}
+
+	sort.SliceStable(levels.Stock, func(i, j int) bool {
+		return levels.Stock[i].Name < levels.Stock[j].Name
+	})
+
+	return c.JSON(http.StatusOK, levels)
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// documentTags returns the string tags of document.
+func documentTags(document bson.M) []string {
+	tags := []string{}
+
+	array, _ := document["tags"].(bson.A)
+	for _, tag := range array {
+		if tag, ok := tag.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// HandleV1Tags responds with the catalog of the tags in use by the objects
+// that are not in the trash, see types.TagCatalog.
+func (h *Handler) HandleV1Tags(c echo.Context) error {
+	var objects [][]string
+
+	for _, collection := range db.TrashCollections {
+		documents, err := h.Store.ReadAll(c.Request().Context(), collection, db.NotTrashed(), &db.ReadOptions{
+			Projection: []string{"tags"},
+		})
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for _, document := range documents {
+			objects = append(objects, documentTags(*document))
+		}
+	}
+
+	return c.JSON(http.StatusOK, types.NewTagCatalog(objects))
+}
+
+// HandleV1TagsRename renames a tag, or a key of structured tags, on every
+// object, see types.TagRename.
+func (h *Handler) HandleV1TagsRename(c echo.Context) error {
+	var data types.TagRename
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with the tag to rename from and to")
+	}
+
+	if data.From == "" || data.To == "" {
+		return errorJSON(c, http.StatusUnprocessableEntity, "Both 'from' and 'to' must be non-empty")
+	}
+
+	if !data.Key {
+		return h.mergeTags(c, []string{data.From}, data.To, data.DryRun)
+	}
+
+	prefix := types.Tag(data.From, "")
+
+	query := bson.D{{Key: "tags", Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}}}
+
+	result, err := h.retag(c.Request().Context(), db.TrashCollections, query, data.DryRun, func(tags []string) []string {
+		renamed := make([]string, 0, len(tags))
+
+		for _, tag := range tags {
+			if key, value, ok := types.ParseTag(tag); ok && key == data.From {
+				tag = types.Tag(data.To, value)
+			}
+
+			if !contains(renamed, tag) {
+				renamed = append(renamed, tag)
+			}
+		}
+
+		return renamed
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// HandleV1TagsMerge replaces several tags by a single one on every object,
+// see types.TagMerge.
+func (h *Handler) HandleV1TagsMerge(c echo.Context) error {
+	var data types.TagMerge
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with the tags to merge from and to")
+	}
+
+	if len(data.From) == 0 || data.To == "" {
+		return errorJSON(c, http.StatusUnprocessableEntity, "Both 'from' and 'to' must be non-empty")
+	}
+
+	return h.mergeTags(c, data.From, data.To, data.DryRun)
+}
+
+// mergeTags replaces the tags from by the tag to on every object.
+func (h *Handler) mergeTags(c echo.Context, from []string, to string, dryRun bool) error {
+	query := bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: from}}}}
+
+	result, err := h.retag(c.Request().Context(), db.TrashCollections, query, dryRun, func(tags []string) []string {
+		merged := make([]string, 0, len(tags))
+
+		for _, tag := range tags {
+			if contains(from, tag) {
+				tag = to
+			}
+
+			if !contains(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+
+		return merged
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// HandleV1TagsApply adds and removes tags on every object matching the filter
+// of the query parameters, see types.TagApply.
+func (h *Handler) HandleV1TagsApply(c echo.Context) error {
+	var data types.TagApply
+
+	if err := c.Bind(&data); err != nil {
+		return bindErrorJSON(c, err, "a JSON object with the tags to add and remove")
+	}
+
+	if len(data.Add) == 0 && len(data.Remove) == 0 {
+		return errorJSON(c, http.StatusUnprocessableEntity, "No tags to add or remove")
+	}
+
+	for _, tag := range data.Add {
+		if tag == "" {
+			return errorJSON(c, http.StatusUnprocessableEntity, "Tags must be non-empty")
+		}
+
+		if contains(data.Remove, tag) {
+			return errorJSON(c, http.StatusUnprocessableEntity, fmt.Sprintf("Tag '%s' cannot be both added and removed", tag))
+		}
+	}
+
+	collections := db.TrashCollections
+
+	if len(data.Kinds) > 0 {
+		collections = nil
+
+		for _, kind := range data.Kinds {
+			collection, ok := kindCollections[kind]
+			if !ok {
+				return errorJSON(c, http.StatusUnprocessableEntity, fmt.Sprintf("Invalid kind '%s', must be one of { component | assembly | kit | location }", kind))
+			}
+
+			collections = append(collections, collection)
+		}
+	}
+
+	// Custom fields are only filtered for a single kind
+	var schema *types.Schema
+
+	if len(collections) == 1 {
+		var err error
+		if schema, err = h.schema(c.Request().Context(), collections[0]); err != nil {
+			return internalErrorJSON(c, err)
+		}
+	}
+
+	query, err := filterQuery(c, schema)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	if len(query) == 0 {
+		return errorJSON(c, http.StatusUnprocessableEntity, "A filter is required, e.g. 'tag=type=ram'")
+	}
+
+	result, err := h.retag(c.Request().Context(), collections, query, data.DryRun, func(tags []string) []string {
+		applied := make([]string, 0, len(tags)+len(data.Add))
+
+		for _, tag := range tags {
+			if !contains(data.Remove, tag) {
+				applied = append(applied, tag)
+			}
+		}
+
+		for _, tag := range data.Add {
+			if !contains(applied, tag) {
+				applied = append(applied, tag)
+			}
+		}
+
+		return applied
+	})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// retag sets the tags of every object of collections matching query, and not
+// in the trash, to the result of change. Objects whose tags are unchanged are
+// left as is. Nothing is changed on dry runs.
+//
+// Objects modified concurrently are read again before changing them.
+func (h *Handler) retag(ctx context.Context, collections []string, query bson.D, dryRun bool, change func(tags []string) []string) (*types.RetagResult, error) {
+	result := &types.RetagResult{DryRun: dryRun, Objects: []types.RetaggedObject{}}
+
+	for _, collection := range collections {
+		documents, err := h.Store.ReadAll(ctx, collection, db.And(query, db.NotTrashed()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documents {
+			id, _ := (*document)["_id"].(primitive.ObjectID)
+
+			for attempt := 1; ; attempt++ {
+				tags, changed := documentTags(*document), false
+
+				retagged := change(tags)
+				if len(retagged) != len(tags) {
+					changed = true
+				}
+				for i := 0; !changed && i < len(tags); i++ {
+					changed = tags[i] != retagged[i]
+				}
+
+				if !changed {
+					break
+				}
+
+				if dryRun {
+					result.Objects = append(result.Objects, retaggedObject(collection, *document, retagged))
+					break
+				}
+
+				update := bson.D{{Key: "$set", Value: bson.D{{Key: "tags", Value: retagged}}}}
+
+				updated, err := db.UpdateIfVersion(ctx, h.Store, collection, id, db.Version(*document), update)
+				if err != nil {
+					return nil, err
+				}
+
+				if updated.MatchedCount > 0 {
+					result.Objects = append(result.Objects, retaggedObject(collection, *document, retagged))
+					result.ModifiedCount += updated.ModifiedCount
+					break
+				}
+
+				if attempt >= patchRetries {
+					return nil, fmt.Errorf("Object %s kept being modified while changing its tags", id.Hex())
+				}
+
+				// Modified since it was read
+				current, err := h.Store.ReadFromID(ctx, collection, id)
+				if err == mongo.ErrNoDocuments {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				if db.IsTrashed(current) {
+					break
+				}
+
+				*document = current
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// retaggedObject returns document of collection, as it is with tags.
+func retaggedObject(collection string, document bson.M, tags []string) types.RetaggedObject {
+	object := types.RetaggedObject{Collection: collection, Tags: tags}
+
+	object.ID, _ = document["_id"].(primitive.ObjectID)
+	object.Name, _ = document["name"].(string)
+
+	return object
+}
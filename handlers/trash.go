@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HandleV1Trash responds with every object in the trash, from the most
+// recently deleted.
+func (h *Handler) HandleV1Trash(c echo.Context) error {
+	trash := types.Trash{Objects: []types.TrashedObject{}}
+
+	for _, collection := range db.TrashCollections {
+		documents, err := h.Store.ReadAll(c.Request().Context(), collection, db.Trashed(), nil)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for _, document := range documents {
+			object := types.TrashedObject{Collection: collection}
+
+			object.ID, _ = (*document)["_id"].(primitive.ObjectID)
+			object.Name, _ = (*document)["name"].(string)
+
+			if deletedAt, ok := (*document)[db.TrashedField].(primitive.DateTime); ok {
+				object.DeletedAt = deletedAt.Time().UTC()
+			}
+
+			if h.TrashRetention > 0 {
+				purgeAt := object.DeletedAt.Add(h.TrashRetention)
+				object.PurgeAt = &purgeAt
+			}
+
+			trash.Objects = append(trash.Objects, object)
+		}
+	}
+
+	sort.SliceStable(trash.Objects, func(i, j int) bool {
+		return trash.Objects[i].DeletedAt.After(trash.Objects[j].DeletedAt)
+	})
+
+	return c.JSON(http.StatusOK, trash)
+}
+
+// HandleV1TrashPurge permanently deletes the objects that stayed in the trash
+// longer than the retention, or every object in the trash with "all=true".
+func (h *Handler) HandleV1TrashPurge(c echo.Context) error {
+	var before time.Time
+
+	switch all := c.QueryParam("all"); all {
+	case "true":
+	case "", "false":
+		if h.TrashRetention <= 0 {
+			return errorJSON(c, http.StatusUnprocessableEntity, "No trash retention is configured, retry with all=true to purge the whole trash")
+		}
+
+		before = time.Now().Add(-h.TrashRetention)
+	default:
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Invalid all '%s', must be one of { true | false }", all))
+	}
+
+	result, err := db.PurgeTrash(c.Request().Context(), h.Store, before)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) HandleV1ComponentRestore(c echo.Context) error {
+	return h.restore(c, "components", c.Param("component"))
+}
+
+func (h *Handler) HandleV1AssemblyRestore(c echo.Context) error {
+	return h.restore(c, "assemblies", c.Param("assembly"))
+}
+
+func (h *Handler) HandleV1KitRestore(c echo.Context) error {
+	return h.restore(c, "kits", c.Param("kit"))
+}
+
+func (h *Handler) HandleV1LocationRestore(c echo.Context) error {
+	return h.restore(c, "locations", c.Param("location"))
+}
+
+// placementFields are the fields of the objects holding the ObjectID of the
+// object they are in.
+var placementFields = []string{"target", "location", "parent"}
+
+// restore takes the object id of collection out of the trash, along with the
+// objects deleted with it by a cascade, and responds with the object.
+//
+// The target, location or parent of the object is cleared if it was purged,
+// and the object is not restored while it is still in the trash. Nothing is
+// changed until the object is known to match its schema and unique fields.
+func (h *Handler) restore(c echo.Context, collection string, id string) error {
+	ctx := c.Request().Context()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	document, err := h.Store.ReadFromID(ctx, collection, objectID)
+	if err == mongo.ErrNoDocuments || (err == nil && !db.IsTrashed(document)) {
+		return errorJSON(c, http.StatusNotFound, fmt.Sprintf("No object with ObjectID %s in the trash", objectID.Hex()))
+	}
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	set, unset := bson.D{}, bson.D{}
+
+	for _, field := range placementFields {
+		target, _ := document[field].(primitive.ObjectID)
+		if target.IsZero() {
+			continue
+		}
+
+		_, targetDocument, err := h.findTrashed(ctx, target)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			// Purged, the restored object is not in it anymore
+			if field == "location" {
+				unset = append(unset, bson.E{Key: field, Value: ""})
+			} else {
+				set = append(set, bson.E{Key: field, Value: primitive.NilObjectID})
+			}
+		case err != nil:
+			return internalErrorJSON(c, err)
+		case db.IsTrashed(targetDocument):
+			return errorJSON(c, http.StatusConflict, fmt.Sprintf("The %s %s of object %s is in the trash, restore it first", field, target.Hex(), objectID.Hex()))
+		}
+	}
+
+	// The schema may have changed, or unique custom fields been reused,
+	// while it was in the trash
+	schema, err := h.schema(ctx, collection)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	values, err := schema.ValidateValues(document["fields"])
+	if err != nil {
+		return errorJSON(c, http.StatusConflict, fmt.Sprintf("Object %s does not match the schema of its kind: %s", objectID.Hex(), err))
+	}
+
+	if err := h.checkUnique(ctx, collection, schema, objectID, values); err != nil {
+		return statusErrorJSON(c, err)
+	}
+
+	count, err := h.restoreSubtree(ctx, collection, objectID, document[db.TrashedField], set, unset, map[primitive.ObjectID]bool{})
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	// Restored meanwhile by another request
+	if count == 0 {
+		return errorJSON(c, http.StatusNotFound, fmt.Sprintf("No object with ObjectID %s in the trash", objectID.Hex()))
+	}
+
+	restored, err := h.Store.ReadFromID(ctx, collection, objectID)
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	setETag(c, restored)
+
+	return c.JSON(http.StatusOK, restored)
+}
+
+// findTrashed is findObject, including the objects in the trash.
+func (h *Handler) findTrashed(ctx context.Context, id primitive.ObjectID) (string, bson.M, error) {
+	for _, collection := range db.TrashCollections {
+		document, err := h.Store.ReadFromID(ctx, collection, id)
+		if err == nil {
+			return collection, document, nil
+		}
+
+		if err != mongo.ErrNoDocuments {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, mongo.ErrNoDocuments
+}
+
+// restoreSubtree restores the object id of collection, setting the fields of
+// set and unsetting those of unset, along with the objects targeting it,
+// directly or through other objects, that were deleted at the same time.
+func (h *Handler) restoreSubtree(ctx context.Context, collection string, id primitive.ObjectID, deletedAt interface{}, set, unset bson.D, visited map[primitive.ObjectID]bool) (int64, error) {
+	if visited[id] {
+		return 0, nil
+	}
+
+	visited[id] = true
+
+	result, err := db.Restore(ctx, h.Store, collection, id, set, unset)
+	if err != nil {
+		return 0, err
+	}
+
+	count := result.ModifiedCount
+
+	filter := bson.D{
+		{Key: "target", Value: id},
+		{Key: db.TrashedField, Value: deletedAt},
+	}
+
+	for _, referrerCollection := range referrerCollections {
+		documents, err := h.Store.ReadAll(ctx, referrerCollection, filter, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, document := range documents {
+			referrerID, _ := (*document)["_id"].(primitive.ObjectID)
+
+			restored, err := h.restoreSubtree(ctx, referrerCollection, referrerID, deletedAt, nil, nil, visited)
+			if err != nil {
+				return 0, err
+			}
+
+			count += restored
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// childCollections are the collections whose objects can target an object of
+// each collection, in the order they are listed in a tree.
+var childCollections = map[string][]string{
+	"kits":       {"assemblies", "components"},
+	"assemblies": {"components"},
+	"components": {},
+}
+
+// collectionKinds are the kinds of the objects of each collection.
+var collectionKinds = map[string]string{
+	"components": "component",
+	"assemblies": "assembly",
+	"kits":       "kit",
+	"locations":  "location",
+}
+
+// kindCollections are the collections of the kinds of objects, as named in
+// the routes.
+var kindCollections = map[string]string{
+	"component": "components",
+	"assembly":  "assemblies",
+	"kit":       "kits",
+	"location":  "locations",
+}
+
+func (h *Handler) HandleV1KitTree(c echo.Context) error {
+	return h.tree(c, "kits", c.Param("kit"))
+}
+
+func (h *Handler) HandleV1AssemblyTree(c echo.Context) error {
+	return h.tree(c, "assemblies", c.Param("assembly"))
+}
+
+// tree responds with the types.Tree of the object idParam of collection,
+// down to the depth of the "depth" query parameter, or to the leaves when it
+// is 0 or unset.
+func (h *Handler) tree(c echo.Context, collection, idParam string) error {
+	ctx := c.Request().Context()
+
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		if err == primitive.ErrInvalidHex {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+
+		return internalErrorJSON(c, err)
+	}
+
+	depth := 0
+
+	if param := c.QueryParam("depth"); param != "" {
+		depth, err = strconv.Atoi(param)
+		if err != nil || depth < 0 {
+			return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Invalid depth '%s', must be a positive integer, or 0 for no limit", param))
+		}
+	}
+
+	document, err := h.Store.ReadFromID(ctx, collection, id)
+	if err == mongo.ErrNoDocuments || (err == nil && db.IsTrashed(document)) {
+		return statusErrorJSON(c, notFound(id))
+	}
+	if err != nil {
+		return internalErrorJSON(c, err)
+	}
+
+	root := treeNode(collection, document)
+
+	// Nodes of the current level, by id, whose children are read next
+	level := map[primitive.ObjectID]*types.Tree{id: &root}
+	levelCollections := map[primitive.ObjectID]string{id: collection}
+
+	// Objects already in the tree, in case targets form a cycle
+	seen := map[primitive.ObjectID]bool{id: true}
+
+	for current := 1; len(level) > 0; current++ {
+		next := map[primitive.ObjectID]*types.Tree{}
+		nextCollections := map[primitive.ObjectID]string{}
+
+		children, err := h.readChildren(ctx, level, levelCollections)
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for parentID, parent := range level {
+			for _, child := range children[parentID] {
+				childID, _ := child.document["_id"].(primitive.ObjectID)
+				if seen[childID] {
+					continue
+				}
+
+				if depth > 0 && current > depth {
+					parent.Truncated = true
+					break
+				}
+
+				seen[childID] = true
+
+				parent.Children = append(parent.Children, treeNode(child.collection, child.document))
+			}
+
+			// Children are only referenced once appended, as appending may
+			// move the previous ones
+			for i := range parent.Children {
+				node := &parent.Children[i]
+				next[node.ID] = node
+				nextCollections[node.ID] = kindCollections[node.Kind]
+			}
+		}
+
+		level, levelCollections = next, nextCollections
+	}
+
+	return c.JSON(http.StatusOK, root)
+}
+
+// child is an object read by readChildren.
+type child struct {
+	collection string
+	document   bson.M
+}
+
+// readChildren returns the objects targeting the objects of level, by target,
+// in the order of childCollections. Objects in the trash are left out.
+//
+// Only the ids of level are read, its trees may be nil.
+func (h *Handler) readChildren(ctx context.Context, level map[primitive.ObjectID]*types.Tree, collections map[primitive.ObjectID]string) (map[primitive.ObjectID][]child, error) {
+	children := map[primitive.ObjectID][]child{}
+
+	// Targets of the children of each collection
+	targets := map[string]bson.A{}
+
+	for id := range level {
+		for _, childCollection := range childCollections[collections[id]] {
+			targets[childCollection] = append(targets[childCollection], id)
+		}
+	}
+
+	for _, childCollection := range []string{"assemblies", "components"} {
+		if len(targets[childCollection]) == 0 {
+			continue
+		}
+
+		query := db.And(bson.D{{Key: "target", Value: bson.D{{Key: "$in", Value: targets[childCollection]}}}}, db.NotTrashed())
+
+		documents, err := h.Store.ReadAll(ctx, childCollection, query, &db.ReadOptions{Sort: bson.D{{Key: "name", Value: 1}}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documents {
+			target, _ := (*document)["target"].(primitive.ObjectID)
+			children[target] = append(children[target], child{childCollection, *document})
+		}
+	}
+
+	return children, nil
+}
+
+// treeNode returns the types.Tree of document of collection, without
+// children.
+func treeNode(collection string, document bson.M) types.Tree {
+	return types.Tree{Node: node(collection, document), Children: []types.Tree{}}
+}
+
+// node returns the types.Node of document of collection.
+func node(collection string, document bson.M) types.Node {
+	n := types.Node{
+		Kind: collectionKinds[collection],
+		Tags: documentTags(document),
+	}
+
+	n.ID, _ = document["_id"].(primitive.ObjectID)
+	n.Name, _ = document["name"].(string)
+	n.Status, _ = document["status"].(string)
+
+	return n
+}
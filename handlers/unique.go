@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"codeberg.org/haulproject/haul/db"
+	"codeberg.org/haulproject/haul/types"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleV1Duplicates responds with the types.Duplicates of the objects not in
+// the trash, the groups of objects whose values of a key only differ by case
+// or whitespace, selected by the types.DuplicateFilter query parameters.
+func (h *Handler) HandleV1Duplicates(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var filter types.DuplicateFilter
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{"component", "assembly", "kit", "location"}
+	}
+
+	for _, key := range filter.Keys {
+		if err := types.ValidateUniqueKey(key); err != nil {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
+		}
+	}
+
+	duplicates := types.Duplicates{Groups: []types.DuplicateGroup{}}
+
+	for _, kind := range kinds {
+		collection, err := schemaCollection(kind)
+		if err != nil {
+			return statusErrorJSON(c, err)
+		}
+
+		keys := filter.Keys
+		if len(keys) == 0 {
+			keys = []string{types.UniqueKeyName}
+
+			for _, key := range h.Unique.Keys(kind) {
+				if key != types.UniqueKeyName {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		documents, err := h.Store.ReadAll(ctx, collection, db.NotTrashed(), &db.ReadOptions{
+			Sort:       bson.D{{Key: "_id", Value: 1}},
+			Projection: []string{"name", "tags"},
+		})
+		if err != nil {
+			return internalErrorJSON(c, err)
+		}
+
+		for _, key := range keys {
+			groups := map[string]*types.DuplicateGroup{}
+
+			for _, document := range documents {
+				id, _ := (*document)["_id"].(primitive.ObjectID)
+				name, _ := (*document)["name"].(string)
+
+				for _, value := range types.UniqueValues(key, name, documentTags(*document)) {
+					near := types.NearValue(value)
+					if near == "" {
+						continue
+					}
+
+					if groups[near] == nil {
+						groups[near] = &types.DuplicateGroup{Kind: kind, Key: key, Value: near}
+					}
+
+					groups[near].Objects = append(groups[near].Objects, types.DuplicateObject{ID: id, Name: name, Value: value})
+				}
+			}
+
+			values := make([]string, 0, len(groups))
+
+			for near, group := range groups {
+				if len(group.Objects) > 1 {
+					values = append(values, near)
+				}
+			}
+
+			sort.Strings(values)
+
+			for _, near := range values {
+				duplicates.Groups = append(duplicates.Groups, *groups[near])
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, duplicates)
+}
+
+// duplicateJSON responds with the conflict of a write refused by a unique
+// key, detailed by types.DuplicateDetails.
+func duplicateJSON(c echo.Context, duplicate *db.DuplicateError) error {
+	// Custom fields are reported as checkUnique does
+	if strings.HasPrefix(duplicate.Key, types.FieldValuesPrefix) {
+		name := strings.TrimPrefix(duplicate.Key, types.FieldValuesPrefix)
+		message := fmt.Sprintf("Field '%s' has the value %v of %s %s", name, duplicate.Value, collectionKinds[duplicate.Collection], duplicate.ID.Hex())
+
+		if duplicate.ID.IsZero() {
+			message = fmt.Sprintf("Field '%s' has the value %v more than once", name, duplicate.Value)
+		}
+
+		return errorDetailsJSON(c, http.StatusConflict, message, types.DuplicateDetails{Field: name, Value: duplicate.Value, ID: duplicate.ID})
+	}
+
+	if duplicate.ID.IsZero() {
+		return errorDetailsJSON(c, http.StatusConflict, fmt.Sprintf("The %s '%v' is given to several objects", duplicate.Key, duplicate.Value), types.DuplicateDetails{
+			Field: duplicate.Key,
+			Value: duplicate.Value,
+		})
+	}
+
+	return errorDetailsJSON(c, http.StatusConflict, fmt.Sprintf("The %s '%v' is already used by %s %s", duplicate.Key, duplicate.Value, collectionKinds[duplicate.Collection], duplicate.ID.Hex()), types.DuplicateDetails{
+		Field: duplicate.Key,
+		Value: duplicate.Value,
+		ID:    duplicate.ID,
+	})
+}
+
+// uniqueTagKeys returns the tag keys, of the unique keys of the objects of
+// collection, whose values are not copied to clones.
+func (h *Handler) uniqueTagKeys(collection string) []string {
+	tagKeys := []string{}
+
+	for _, key := range h.Unique.Keys(collectionKinds[collection]) {
+		if key != types.UniqueKeyName {
+			tagKeys = append(tagKeys, strings.TrimPrefix(key, types.TagValuesPrefix))
+		}
+	}
+
+	return tagKeys
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"codeberg.org/haulproject/haul/db"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// etag returns the entity tag of an object of the given version.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// setETag sets the ETag header of the response to the version of document.
+func setETag(c echo.Context, document bson.M) {
+	c.Response().Header().Set("ETag", etag(db.Version(document)))
+}
+
+// ifMatch returns the version required by the If-Match header of the
+// request, if there is one. "*" matches any version.
+func ifMatch(c echo.Context) (int64, bool, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version < 0 {
+		return 0, false, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid If-Match '%s', must be the ETag of the object, e.g. \"3\"", header)}
+	}
+
+	return version, true, nil
+}
+
+// versionConflict returns the error responded when the object id is not at
+// the version expected by the request anymore.
+func versionConflict(c echo.Context, id primitive.ObjectID, version int64) error {
+	c.Response().Header().Set("ETag", etag(version))
+
+	return &statusError{http.StatusPreconditionFailed, fmt.Sprintf("Object %s was modified by someone else, its version is now %d. Read it again before retrying", id.Hex(), version)}
+}
+
+// updateFromID applies data to the object id of collection, if its version
+// is the one required by the If-Match header of the request.
+//
+// current is the object as read by the handler to compute data, or nil. When
+// set, data is only applied if the object was not modified since, whatever
+// the If-Match header.
+//
+// Changes of status are validated against h.Statuses, and changes of target
+// and location against each other, see place, reading the object if current
+// is nil.
+//
+// A *statusError is returned on version conflicts, invalid changes of
+// status, or if the object does not exist or is in the trash.
+func (h *Handler) updateFromID(c echo.Context, collection string, id primitive.ObjectID, data bson.D, current bson.M) (*mongo.UpdateResult, error) {
+	ctx := c.Request().Context()
+
+	version, conditional, err := ifMatch(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if nameEmptied(data) {
+		return nil, &statusError{http.StatusUnprocessableEntity, "name cannot be empty"}
+	}
+
+	data, current, err = h.place(ctx, collection, id, data, current)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err = h.checkMoveOut(c, collection, id, data, current)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects in the trash are not edited until they are restored
+	if current != nil && db.IsTrashed(current) {
+		return nil, notFound(id)
+	}
+
+	if status, changed := statusChange(data); changed && h.Statuses.Enforced() {
+		if current == nil {
+			current, err = h.Store.ReadFromID(ctx, collection, id)
+			if err == mongo.ErrNoDocuments || (err == nil && db.IsTrashed(current)) {
+				return nil, notFound(id)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		from, _ := current["status"].(string)
+
+		if err := h.Statuses.ValidateTransition(from, status); err != nil {
+			return nil, &statusError{http.StatusUnprocessableEntity, err.Error()}
+		}
+	}
+
+	if current != nil {
+		if conditional && version != db.Version(current) {
+			return nil, versionConflict(c, id, db.Version(current))
+		}
+
+		version, conditional = db.Version(current), true
+	}
+
+	query := db.And(bson.D{{Key: "_id", Value: id}}, db.NotTrashed())
+
+	if !conditional {
+		result, err := h.Store.UpdateMany(ctx, collection, query, data)
+		if err == nil && result.MatchedCount == 0 {
+			return nil, notFound(id)
+		}
+
+		return result, err
+	}
+
+	result, err := h.Store.UpdateMany(ctx, collection, db.And(query, db.VersionQuery(version)), data)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		document, err := h.Store.ReadFromID(ctx, collection, id)
+		if err == mongo.ErrNoDocuments || (err == nil && db.IsTrashed(document)) {
+			return nil, notFound(id)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, versionConflict(c, id, db.Version(document))
+	}
+
+	c.Response().Header().Set("ETag", etag(version+1))
+
+	return result, nil
+}
+
+// nameEmptied returns whether the update data sets the name to "" or unsets
+// it, which the store refuses.
+func nameEmptied(data bson.D) bool {
+	for _, operator := range data {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			if field.Key != "name" {
+				continue
+			}
+
+			switch operator.Key {
+			case "$set":
+				if name, ok := field.Value.(string); ok && name == "" {
+					return true
+				}
+			case "$unset":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// statusChange returns the status set by the update data, "" if it unsets
+// it, and whether it changes the status at all.
+func statusChange(data bson.D) (string, bool) {
+	for _, operator := range data {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			if field.Key != "status" {
+				continue
+			}
+
+			switch operator.Key {
+			case "$set":
+				if status, ok := field.Value.(string); ok {
+					return status, true
+				}
+
+				return fmt.Sprint(field.Value), true
+			case "$unset":
+				return "", true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Contents is the tree of assemblies and components that targeted a kit at
+// a given time.
+type Contents struct {
+	At         time.Time         `json:"at"`
+	Kit        KitWithID         `json:"kit"`
+	Assemblies []AssemblyWithID  `json:"assemblies"`
+	Components []ComponentWithID `json:"components"`
+}
+
+// TabbyPrint prints the kit, then the objects targeting it, each indented
+// under its target.
+func (c *Contents) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("name", "kind", "id", "status", "tags")
+
+	add := func(depth int, kind string, id primitive.ObjectID, name, status string, tags []string) error {
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+
+		t.AddLine(strings.Repeat("  ", depth)+name, kind, id.Hex(), status, string(tagsJSON))
+		return nil
+	}
+
+	var children func(target primitive.ObjectID, depth int) error
+
+	children = func(target primitive.ObjectID, depth int) error {
+		for _, assembly := range c.Assemblies {
+			if assembly.Target != target {
+				continue
+			}
+
+			if err := add(depth, "assembly", assembly.ID, assembly.Name, assembly.Status, assembly.Tags); err != nil {
+				return err
+			}
+
+			if err := children(assembly.ID, depth+1); err != nil {
+				return err
+			}
+		}
+
+		for _, component := range c.Components {
+			if component.Target != target {
+				continue
+			}
+
+			if err := add(depth, "component", component.ID, component.Name, component.Status, component.Tags); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := add(0, "kit", c.Kit.ID, c.Kit.Name, c.Kit.Status, c.Kit.Tags); err != nil {
+		return err
+	}
+
+	if err := children(c.Kit.ID, 1); err != nil {
+		return err
+	}
+
+	t.Print()
+	return nil
+}
+
+// Snapshot is every component, assembly, kit and location as they were at a
+// given time.
+type Snapshot struct {
+	At time.Time `json:"at"`
+
+	Components []ComponentWithID `json:"components"`
+	Assemblies []AssemblyWithID  `json:"assemblies"`
+	Kits       []KitWithID       `json:"kits"`
+	Locations  []LocationWithID  `json:"locations"`
+}
@@ -0,0 +1,35 @@
+package types
+
+// Codes of the Error responded for each status.
+const (
+	ErrorCodeBadRequest         = "bad_request"         // 400, malformed request
+	ErrorCodeUnauthorized       = "unauthorized"        // 401, missing or invalid key
+	ErrorCodeForbidden          = "forbidden"           // 403
+	ErrorCodeNotFound           = "not_found"           // 404, missing object or route
+	ErrorCodeMethodNotAllowed   = "method_not_allowed"  // 405
+	ErrorCodeConflict           = "conflict"            // 409, conflicts with the state of other objects
+	ErrorCodePreconditionFailed = "precondition_failed" // 412, object modified since, see If-Match
+	ErrorCodeTooLarge           = "too_large"           // 413
+	ErrorCodeUnsupportedMedia   = "unsupported_media"   // 415, see the Content-Type of the PATCH routes
+	ErrorCodeUnprocessable      = "unprocessable"       // 422, well-formed but invalid request
+	ErrorCodeInternal           = "internal"            // 500
+	ErrorCodeUnavailable        = "unavailable"         // 503, database unreachable
+	ErrorCodeTimeout            = "timeout"             // 504, see 'server.timeout'
+)
+
+// Error is the body of every error response of the API.
+type Error struct {
+	// Code identifies the kind of error, e.g. "not_found"
+	Code string `json:"code"`
+
+	// Message explains the error to humans
+	Message string `json:"message"`
+
+	// Details depend on the error, e.g. the ReferencedDetails of an object
+	// that cannot be deleted
+	Details interface{} `json:"details,omitempty"`
+
+	// RequestID is also the X-Request-Id header of the response, and is
+	// logged by the server
+	RequestID string `json:"request_id,omitempty"`
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the history of an object.
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionTags    = "tags"    // Only the tags changed
+	HistoryActionTarget  = "target"  // Only the target changed
+	HistoryActionDelete  = "delete"  // Moved to the trash
+	HistoryActionRestore = "restore" // Restored from the trash
+	HistoryActionPurge   = "purge"   // Permanently deleted
+)
+
+// HistoryEntry records a single change made to an object.
+type HistoryEntry struct {
+	ID primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+
+	// Collection and Object identify the changed object
+	Collection string             `json:"collection" bson:"collection"`
+	Object     primitive.ObjectID `json:"object" bson:"object"`
+
+	Action   string    `json:"action" bson:"action"`
+	Actor    string    `json:"actor" bson:"actor"` // e.g. "key:alice", or "anonymous" without key authentication
+	RemoteIP string    `json:"remote_ip" bson:"remote_ip"`
+	Time     time.Time `json:"time" bson:"time"`
+
+	// Before is nil on creation, and After is nil once purged
+	Before bson.M `json:"before" bson:"before"`
+	After  bson.M `json:"after" bson:"after"`
+
+	Diff []FieldChange `json:"diff" bson:"diff"`
+}
+
+// FieldChange is the change of value of a single field of an object.
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// History is the list of changes made to an object, from oldest to newest.
+type History struct {
+	Entries []HistoryEntry `json:"history"`
+}
+
+func (h *History) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("time", "action", "actor", "changes")
+
+	for _, entry := range h.Entries {
+		var changes []string
+
+		for _, change := range entry.Diff {
+			before, err := json.Marshal(change.Before)
+			if err != nil {
+				return err
+			}
+
+			after, err := json.Marshal(change.After)
+			if err != nil {
+				return err
+			}
+
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", change.Field, before, after))
+		}
+
+		t.AddLine(entry.Time.Format(time.RFC3339), entry.Action, entry.Actor, strings.Join(changes, "; "))
+	}
+
+	t.Print()
+	return nil
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Checkout lends a kit to Borrower until Due.
+type Checkout struct {
+	Borrower string    `json:"borrower"`
+	Due      time.Time `json:"due"`
+	Note     string    `json:"note,omitempty"`
+}
+
+// Checkin returns a lent kit.
+type Checkin struct {
+	Note string `json:"note,omitempty"`
+}
+
+// LoanFilter selects loans. LoanFilter fields are read from, and written to,
+// the query parameters named in their `query` tag.
+type LoanFilter struct {
+	Active   bool   `query:"active"`   // Not returned yet
+	Overdue  bool   `query:"overdue"`  // Not returned yet, and past their due date
+	Borrower string `query:"borrower"` // Lent to Borrower
+}
+
+// Values returns the query parameters representing the LoanFilter.
+func (f LoanFilter) Values() url.Values {
+	values := url.Values{}
+
+	if f.Active {
+		values.Set("active", "true")
+	}
+
+	if f.Overdue {
+		values.Set("overdue", "true")
+	}
+
+	if f.Borrower != "" {
+		values.Set("borrower", f.Borrower)
+	}
+
+	return values
+}
+
+// Loan records that an object was lent, from its check-out to its check-in.
+type Loan struct {
+	ID         primitive.ObjectID `json:"_id" bson:"_id"`
+	Collection string             `json:"collection" bson:"collection"`
+	Object     primitive.ObjectID `json:"object" bson:"object"`
+	Name       string             `json:"name" bson:"name"` // Name of the object when checked out
+
+	Borrower string    `json:"borrower" bson:"borrower"`
+	Due      time.Time `json:"due" bson:"due"`
+	Note     string    `json:"note,omitempty" bson:"note,omitempty"`
+
+	CheckedOutAt time.Time `json:"checked_out_at" bson:"checked_out_at"`
+	CheckedOutBy string    `json:"checked_out_by" bson:"checked_out_by"` // Actor of the check-out
+
+	// ReturnedAt is unset until the object is checked in
+	ReturnedAt *time.Time `json:"returned_at,omitempty" bson:"returned_at,omitempty"`
+	ReturnedBy string     `json:"returned_by,omitempty" bson:"returned_by,omitempty"`
+	ReturnNote string     `json:"return_note,omitempty" bson:"return_note,omitempty"`
+}
+
+// Active reports whether the object is still lent.
+func (l Loan) Active() bool {
+	return l.ReturnedAt == nil
+}
+
+// Overdue reports whether the object is still lent after its due date.
+func (l Loan) Overdue(now time.Time) bool {
+	return l.Active() && now.After(l.Due)
+}
+
+// Overlaps reports whether the object is lent at some time between start and
+// end. An object still lent after its due date is lent until now at least.
+func (l Loan) Overlaps(start, end, now time.Time) bool {
+	until := l.Due
+
+	if l.ReturnedAt != nil {
+		until = *l.ReturnedAt
+	} else if now.After(until) {
+		until = now
+	}
+
+	return l.CheckedOutAt.Before(end) && start.Before(until)
+}
+
+// state describes l as "returned", "overdue" or "out".
+func (l Loan) state(now time.Time) string {
+	switch {
+	case !l.Active():
+		return "returned"
+	case l.Overdue(now):
+		return "overdue"
+	default:
+		return "out"
+	}
+}
+
+func (l *Loan) TabbyPrint() error {
+	return (&Loans{Loans: []Loan{*l}}).TabbyPrint()
+}
+
+// Loans lists loans, e.g. the loans of an object from oldest to newest.
+type Loans struct {
+	Loans []Loan `json:"loans"`
+}
+
+func (l *Loans) TabbyPrint() error {
+	tab := tabby.New()
+	now := time.Now()
+
+	tab.AddHeader("id", "object", "name", "borrower", "due", "state", "checked_out_at", "returned_at", "note")
+
+	for _, loan := range l.Loans {
+		returnedAt := ""
+		if loan.ReturnedAt != nil {
+			returnedAt = loan.ReturnedAt.Format(time.RFC3339)
+		}
+
+		note := loan.Note
+		if loan.ReturnNote != "" {
+			note = fmt.Sprintf("%s (returned: %s)", note, loan.ReturnNote)
+		}
+
+		tab.AddLine(loan.ID.Hex(), loan.Object.Hex(), loan.Name, loan.Borrower, loan.Due.Format(time.RFC3339), loan.state(now), loan.CheckedOutAt.Format(time.RFC3339), returnedAt, note)
+	}
+
+	tab.Print()
+	return nil
+}
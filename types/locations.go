@@ -0,0 +1,204 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of locations, from the largest to the smallest.
+const (
+	LocationSite  = "site"
+	LocationRoom  = "room"
+	LocationRack  = "rack"
+	LocationShelf = "shelf"
+)
+
+// LocationKinds are the kinds of locations, from the largest to the smallest.
+// A location can only be inside a location of a larger kind, e.g. a rack in a
+// room or directly in a site.
+var LocationKinds = []string{LocationSite, LocationRoom, LocationRack, LocationShelf}
+
+// locationRank returns the position of kind in LocationKinds, or -1 if it is
+// not a kind of location.
+func locationRank(kind string) int {
+	for i, k := range LocationKinds {
+		if k == kind {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ValidateLocationKind returns an error if kind is not one of LocationKinds.
+func ValidateLocationKind(kind string) error {
+	if locationRank(kind) < 0 {
+		return fmt.Errorf("Invalid location kind '%s', must be one of { %s }", kind, strings.Join(LocationKinds, " | "))
+	}
+
+	return nil
+}
+
+// ValidateLocationParent returns an error if a location of kind cannot be
+// inside a location of parentKind.
+func ValidateLocationParent(kind, parentKind string) error {
+	if locationRank(parentKind) >= locationRank(kind) {
+		return fmt.Errorf("A %s cannot be inside a %s, its parent must be a larger kind of location among { %s }", kind, parentKind, strings.Join(LocationKinds, " > "))
+	}
+
+	return nil
+}
+
+// Location is a place where kits, and assemblies and components without a
+// target, are stored.
+type Location struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	Kind string   `json:"kind"` // One of LocationKinds
+
+	// A location's Parent should point to the ObjectID of the larger location
+	// it is in, e.g. the room of a rack
+	Parent primitive.ObjectID `json:"parent"`
+
+	// Fields are the custom fields of the location, see Schema
+	Fields map[string]interface{} `json:"fields" bson:"fields,omitempty"`
+}
+
+type LocationWithID struct {
+	ID primitive.ObjectID `json:"_id"`
+	Location
+
+	// Version is incremented by every change, see the ETag and If-Match headers
+	Version int64 `json:"version"`
+}
+
+type Locations struct {
+	Locations []Location `json:"locations"`
+}
+
+type LocationsWithID struct {
+	LocationsWithID []LocationWithID `json:"locations"`
+	Page
+}
+
+func (l *Location) TabbyPrint() error {
+	t := tabby.New()
+
+	names := fieldNames(l.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "kind", "parent"}, names)...)
+
+	tags, err := json.Marshal(l.Tags)
+	if err != nil {
+		return err
+	}
+
+	parentid, err := json.Marshal(l.Parent)
+	if err != nil {
+		return err
+	}
+
+	t.AddLine(fieldCells([]interface{}{l.Name, string(tags), l.Kind, string(parentid)}, names, l.Fields)...)
+
+	t.Print()
+	return nil
+}
+
+func (l *LocationWithID) TabbyPrint() error {
+	t := tabby.New()
+
+	names := fieldNames(l.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "kind", "parent"}, names)...)
+
+	tags, err := json.Marshal(l.Tags)
+	if err != nil {
+		return err
+	}
+
+	objectid, err := json.Marshal(l.ID)
+	if err != nil {
+		return err
+	}
+
+	parentid, err := json.Marshal(l.Parent)
+	if err != nil {
+		return err
+	}
+
+	t.AddLine(fieldCells([]interface{}{string(objectid), l.Name, string(tags), l.Kind, string(parentid)}, names, l.Fields)...)
+
+	t.Print()
+	return nil
+}
+
+func (l *Locations) TabbyPrint() error {
+	t := tabby.New()
+
+	fields := make([]map[string]interface{}, len(l.Locations))
+
+	for i, location := range l.Locations {
+		fields[i] = location.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "kind", "parent"}, names)...)
+
+	for _, location := range l.Locations {
+		tags, err := json.Marshal(location.Tags)
+		if err != nil {
+			return err
+		}
+
+		parentid, err := json.Marshal(location.Parent)
+		if err != nil {
+			return err
+		}
+
+		t.AddLine(fieldCells([]interface{}{location.Name, string(tags), location.Kind, string(parentid)}, names, location.Fields)...)
+	}
+
+	t.Print()
+	return nil
+}
+
+func (l *LocationsWithID) TabbyPrint() error {
+	t := tabby.New()
+
+	fields := make([]map[string]interface{}, len(l.LocationsWithID))
+
+	for i, location := range l.LocationsWithID {
+		fields[i] = location.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "kind", "parent"}, names)...)
+
+	for _, location := range l.LocationsWithID {
+		tags, err := json.Marshal(location.Tags)
+		if err != nil {
+			return err
+		}
+
+		objectid, err := json.Marshal(location.ID)
+		if err != nil {
+			return err
+		}
+
+		parentid, err := json.Marshal(location.Parent)
+		if err != nil {
+			return err
+		}
+
+		t.AddLine(fieldCells([]interface{}{string(objectid), location.Name, string(tags), location.Kind, string(parentid)}, names, location.Fields)...)
+	}
+
+	t.Print()
+	return nil
+}
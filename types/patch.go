@@ -0,0 +1,24 @@
+package types
+
+// Content types of the bodies accepted by the PATCH routes.
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7386, a partial object
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902, a list of PatchOperation
+)
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902), e.g.
+// {"op": "add", "path": "/tags/-", "value": "type=ram"}.
+type PatchOperation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" or "test"
+	Op string `json:"op"`
+
+	// Path is the JSON Pointer (RFC 6901) of the value to change, e.g.
+	// "/status"
+	Path string `json:"path"`
+
+	// From is the JSON Pointer of the value to move or copy
+	From string `json:"from,omitempty"`
+
+	// Value is the value to add, replace or test
+	Value interface{} `json:"value,omitempty"`
+}
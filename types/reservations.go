@@ -0,0 +1,230 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reserve books a kit for Holder, from Start until End.
+type Reserve struct {
+	Holder string    `json:"holder"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Note   string    `json:"note,omitempty"`
+}
+
+// Reservation books a kit in advance, from Start until End. A kit cannot be
+// reserved twice at the same time, nor while it is lent.
+type Reservation struct {
+	ID   primitive.ObjectID `json:"_id" bson:"_id"`
+	Kit  primitive.ObjectID `json:"kit" bson:"kit"`
+	Name string             `json:"name" bson:"name"` // Name of the kit when reserved
+
+	Holder string    `json:"holder" bson:"holder"`
+	Start  time.Time `json:"start" bson:"start"`
+	End    time.Time `json:"end" bson:"end"`
+	Note   string    `json:"note,omitempty" bson:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	CreatedBy string    `json:"created_by" bson:"created_by"` // Actor of the reservation
+}
+
+// Overlaps reports whether r books its kit at some time between start and
+// end. A reservation ending when another starts does not overlap it.
+func (r Reservation) Overlaps(start, end time.Time) bool {
+	return r.Start.Before(end) && start.Before(r.End)
+}
+
+func (r *Reservation) TabbyPrint() error {
+	return (&Reservations{Reservations: []Reservation{*r}}).TabbyPrint()
+}
+
+// Reservations lists reservations, e.g. the reservations of a kit from the
+// earliest start.
+type Reservations struct {
+	Reservations []Reservation `json:"reservations"`
+}
+
+func (r *Reservations) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("id", "kit", "name", "holder", "start", "end", "note")
+
+	for _, reservation := range r.Reservations {
+		tab.AddLine(reservation.ID.Hex(), reservation.Kit.Hex(), reservation.Name, reservation.Holder, reservation.Start.Format(time.RFC3339), reservation.End.Format(time.RFC3339), reservation.Note)
+	}
+
+	tab.Print()
+	return nil
+}
+
+// ICalendar returns the reservations as an iCalendar (RFC 5545) feed, with
+// an event per reservation. product identifies the feed, e.g. its host.
+func (r *Reservations) ICalendar(product string) string {
+	var b strings.Builder
+
+	line := func(name, value string) {
+		b.WriteString(foldICalendar(name + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//haul//"+escapeICalendar(product)+"//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", "haul reservations")
+
+	for _, reservation := range r.Reservations {
+		summary := fmt.Sprintf("%s (%s)", reservation.Name, reservation.Holder)
+
+		line("BEGIN", "VEVENT")
+		line("UID", reservation.ID.Hex()+"@haul")
+		line("DTSTAMP", formatICalendar(reservation.CreatedAt))
+		line("DTSTART", formatICalendar(reservation.Start))
+		line("DTEND", formatICalendar(reservation.End))
+		line("SUMMARY", escapeICalendar(summary))
+
+		if reservation.Note != "" {
+			line("DESCRIPTION", escapeICalendar(reservation.Note))
+		}
+
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	return b.String()
+}
+
+// formatICalendar formats t as an iCalendar UTC date-time.
+func formatICalendar(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICalendar escapes the special characters of an iCalendar text value.
+func escapeICalendar(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// foldICalendar returns the content line, folded into lines of at most 75
+// octets without splitting a character, and terminated by CRLF.
+func foldICalendar(line string) string {
+	var b strings.Builder
+
+	width := 0
+
+	for _, r := range line {
+		size := len(string(r))
+
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+
+		b.WriteRune(r)
+		width += size
+	}
+
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// ReservationConflicts are the details of the Error returned when reserving
+// a kit, or lending it, at a time it is already reserved or lent.
+type ReservationConflicts struct {
+	Reservations []Reservation `json:"reservations"`
+	Loans        []Loan        `json:"loans"`
+}
+
+func (r ReservationConflicts) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("kind", "id", "holder", "start", "end")
+
+	for _, reservation := range r.Reservations {
+		tab.AddLine("reservation", reservation.ID.Hex(), reservation.Holder, reservation.Start.Format(time.RFC3339), reservation.End.Format(time.RFC3339))
+	}
+
+	for _, loan := range r.Loans {
+		tab.AddLine("loan", loan.ID.Hex(), loan.Borrower, loan.CheckedOutAt.Format(time.RFC3339), loan.Due.Format(time.RFC3339))
+	}
+
+	tab.Print()
+	return nil
+}
+
+/*
+ReservationFilter selects reservations.
+
+ReservationFilter fields are read from, and written to, the query parameters
+named in their `query` tag. From and To are RFC 3339 times.
+*/
+type ReservationFilter struct {
+	Kit    string `query:"kit"`    // Reserves the kit of ObjectID Kit
+	Holder string `query:"holder"` // Reserved for Holder
+	From   string `query:"from"`   // Ends after From
+	To     string `query:"to"`     // Starts before To
+}
+
+// Values returns the query parameters representing the ReservationFilter.
+func (f ReservationFilter) Values() url.Values {
+	values := url.Values{}
+
+	if f.Kit != "" {
+		values.Set("kit", f.Kit)
+	}
+
+	if f.Holder != "" {
+		values.Set("holder", f.Holder)
+	}
+
+	if f.From != "" {
+		values.Set("from", f.From)
+	}
+
+	if f.To != "" {
+		values.Set("to", f.To)
+	}
+
+	return values
+}
+
+// Availability lists the kits that are neither reserved nor lent at any time
+// between Start and End.
+type Availability struct {
+	Start time.Time   `json:"start"`
+	End   time.Time   `json:"end"`
+	Kits  []KitWithID `json:"kits"`
+}
+
+func (a *Availability) TabbyPrint() error {
+	tab := tabby.New()
+
+	fields := make([]map[string]interface{}, len(a.Kits))
+
+	for i, kit := range a.Kits {
+		fields[i] = kit.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	tab.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status"}, names)...)
+
+	for _, kit := range a.Kits {
+		tags, err := json.Marshal(kit.Tags)
+		if err != nil {
+			return err
+		}
+
+		tab.AddLine(fieldCells([]interface{}{kit.ID.Hex(), kit.Name, string(tags), kit.Status}, names, kit.Fields)...)
+	}
+
+	tab.Print()
+	return nil
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"github.com/cheynewallace/tabby"
+)
+
+// UpdateResult is the number of objects selected and changed by an update.
+type UpdateResult struct {
+	MatchedCount  int64 `json:"MatchedCount"`
+	ModifiedCount int64 `json:"ModifiedCount"`
+}
+
+func (r UpdateResult) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("matched", "modified")
+	t.AddLine(r.MatchedCount, r.ModifiedCount)
+
+	t.Print()
+	return nil
+}
+
+// DeleteResult is the number of objects deleted.
+type DeleteResult struct {
+	DeletedCount int64 `json:"DeletedCount"`
+}
+
+func (r DeleteResult) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("deleted")
+	t.AddLine(r.DeletedCount)
+
+	t.Print()
+	return nil
+}
@@ -0,0 +1,378 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of custom fields.
+const (
+	FieldString  = "string"
+	FieldInteger = "integer"
+	FieldDecimal = "decimal"
+	FieldBoolean = "boolean"
+	FieldDate    = "date" // Day, or time, stored as a date in UTC
+	FieldEnum    = "enum" // One of the Values of the field
+)
+
+// FieldTypes are the types of custom fields.
+var FieldTypes = []string{FieldString, FieldInteger, FieldDecimal, FieldBoolean, FieldDate, FieldEnum}
+
+// fieldName is the format of the names of custom fields, which are also the
+// keys of their filters, e.g. "field.purchase_date.min".
+var fieldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Field describes a custom field of the objects of a kind, set under their
+// "fields", e.g. { "fields": { "serial": "SN-1234" } }.
+type Field struct {
+	Name     string   `json:"name" bson:"name"`
+	Type     string   `json:"type" bson:"type"`                         // One of FieldTypes
+	Unique   bool     `json:"unique,omitempty" bson:"unique,omitempty"` // No two objects of the kind share a value
+	Required bool     `json:"required,omitempty" bson:"required,omitempty"`
+	Values   []string `json:"values,omitempty" bson:"values,omitempty"` // Allowed values of an enum
+}
+
+// Schema describes the custom fields of the objects of a kind. Objects of a
+// kind without a schema have no custom fields.
+type Schema struct {
+	Kind   string  `json:"kind" bson:"kind"`
+	Fields []Field `json:"fields" bson:"fields"`
+}
+
+// Validate returns an error if the schema itself is invalid.
+func (s *Schema) Validate() error {
+	names := map[string]bool{}
+
+	for _, field := range s.Fields {
+		if !fieldName.MatchString(field.Name) {
+			return fmt.Errorf("Invalid field name '%s', must be lowercase letters, digits and '_', starting with a letter", field.Name)
+		}
+
+		if names[field.Name] {
+			return fmt.Errorf("Field '%s' is defined twice", field.Name)
+		}
+
+		names[field.Name] = true
+
+		switch field.Type {
+		case FieldString, FieldInteger, FieldDecimal, FieldBoolean, FieldDate:
+			if len(field.Values) > 0 {
+				return fmt.Errorf("Field '%s' has values, which are only allowed for the %s type", field.Name, FieldEnum)
+			}
+		case FieldEnum:
+			if len(field.Values) == 0 {
+				return fmt.Errorf("Field '%s' is an %s without values", field.Name, FieldEnum)
+			}
+		default:
+			return fmt.Errorf("Invalid type '%s' of field '%s', must be one of { %s }", field.Type, field.Name, strings.Join(FieldTypes, " | "))
+		}
+	}
+
+	return nil
+}
+
+// Field returns the field of the schema called name, and whether it exists.
+func (s *Schema) Field(name string) (Field, bool) {
+	if s != nil {
+		for _, field := range s.Fields {
+			if field.Name == name {
+				return field, true
+			}
+		}
+	}
+
+	return Field{}, false
+}
+
+// Names returns the names of the fields of the schema, in order.
+func (s *Schema) Names() []string {
+	names := []string{}
+
+	if s != nil {
+		for _, field := range s.Fields {
+			names = append(names, field.Name)
+		}
+	}
+
+	return names
+}
+
+/*
+ValidateValues returns the custom fields values, as decoded from JSON or BSON,
+converted to the types of the schema, e.g. dates to time.Time and integers to
+int64.
+
+An error is returned for unknown fields, values of the wrong type, and
+missing required fields. A nil schema has no fields.
+*/
+func (s *Schema) ValidateValues(values interface{}) (map[string]interface{}, error) {
+	fields, err := fieldValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	validated := map[string]interface{}{}
+
+	for name, value := range fields {
+		field, ok := s.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("Unknown field '%s', must be one of { %s }", name, strings.Join(s.Names(), " | "))
+		}
+
+		if value == nil {
+			continue
+		}
+
+		if validated[name], err = field.Convert(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if s != nil {
+		for _, field := range s.Fields {
+			if _, ok := validated[field.Name]; field.Required && !ok {
+				return nil, fmt.Errorf("Field '%s' is required", field.Name)
+			}
+		}
+	}
+
+	return validated, nil
+}
+
+// fieldValues returns the custom fields values as a map.
+func fieldValues(values interface{}) (map[string]interface{}, error) {
+	switch values := values.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return values, nil
+	case bson.M:
+		return values, nil
+	case bson.D:
+		fields := map[string]interface{}{}
+
+		for _, value := range values {
+			fields[value.Key] = value.Value
+		}
+
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("fields must be an object, e.g. { \"serial\": \"SN-1234\" }")
+	}
+}
+
+// Convert returns value, as decoded from JSON or BSON, converted to the type
+// of the field.
+func (f Field) Convert(value interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("Invalid value %v of field '%s', must be a %s", value, f.Name, f.Type)
+
+	switch f.Type {
+	case FieldString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case FieldEnum:
+		if s, ok := value.(string); ok {
+			return f.Parse(s)
+		}
+	case FieldBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case FieldInteger:
+		switch n := value.(type) {
+		case int32:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+				return int64(n), nil
+			}
+		}
+	case FieldDecimal:
+		switch n := value.(type) {
+		case int32:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case FieldDate:
+		switch t := value.(type) {
+		case string:
+			return f.Parse(t)
+		case time.Time:
+			return t.UTC(), nil
+		case primitive.DateTime:
+			return t.Time().UTC(), nil
+		}
+	}
+
+	return nil, invalid
+}
+
+// Parse returns the value of the field written as text, e.g. in a query
+// parameter. Dates are RFC 3339 times or days, e.g. 2026-11-01.
+func (f Field) Parse(text string) (interface{}, error) {
+	invalid := fmt.Errorf("Invalid value '%s' of field '%s', must be a %s", text, f.Name, f.Type)
+
+	switch f.Type {
+	case FieldString:
+		return text, nil
+	case FieldEnum:
+		for _, value := range f.Values {
+			if value == text {
+				return text, nil
+			}
+		}
+
+		return nil, fmt.Errorf("Invalid value '%s' of field '%s', must be one of { %s }", text, f.Name, strings.Join(f.Values, " | "))
+	case FieldBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b, nil
+		}
+	case FieldInteger:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+	case FieldDecimal:
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n, nil
+		}
+	case FieldDate:
+		if t, err := time.Parse("2006-01-02", text); err == nil {
+			return t, nil
+		}
+
+		if t, err := time.Parse(time.RFC3339, text); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return nil, invalid
+}
+
+func (s *Schema) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("name", "type", "unique", "required", "values")
+
+	for _, field := range s.Fields {
+		t.AddLine(field.Name, field.Type, field.Unique, field.Required, strings.Join(field.Values, " | "))
+	}
+
+	t.Print()
+	return nil
+}
+
+// SchemaConflict is an object that does not match a new schema of its kind.
+type SchemaConflict struct {
+	ID    primitive.ObjectID `json:"_id"`
+	Name  string             `json:"name"`
+	Error string             `json:"error"`
+}
+
+// SchemaConflicts are the details of the Error returned when changing a
+// schema that existing objects do not match.
+type SchemaConflicts struct {
+	Objects []SchemaConflict `json:"objects"`
+}
+
+func (s SchemaConflicts) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("id", "name", "error")
+
+	for _, object := range s.Objects {
+		t.AddLine(object.ID.Hex(), object.Name, object.Error)
+	}
+
+	t.Print()
+	return nil
+}
+
+// DuplicateDetails are the details of the Error returned when setting a
+// unique field to the value of another object.
+type DuplicateDetails struct {
+	Field string             `json:"field"`
+	Value interface{}        `json:"value"`
+	ID    primitive.ObjectID `json:"_id"` // The object that already has the value
+}
+
+func (d DuplicateDetails) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("field", "value", "id")
+	t.AddLine(d.Field, formatField(d.Value), d.ID.Hex())
+
+	t.Print()
+	return nil
+}
+
+// fieldNames returns the sorted names of the custom fields set in any of
+// fields, printed as additional columns.
+func fieldNames(fields ...map[string]interface{}) []string {
+	set := map[string]bool{}
+
+	for _, values := range fields {
+		for name := range values {
+			set[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(set))
+
+	for name := range set {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// fieldColumns returns header followed by the names of custom fields.
+func fieldColumns(header []interface{}, names []string) []interface{} {
+	for _, name := range names {
+		header = append(header, name)
+	}
+
+	return header
+}
+
+// fieldCells returns cells followed by the values of the custom fields names.
+func fieldCells(cells []interface{}, names []string, fields map[string]interface{}) []interface{} {
+	for _, name := range names {
+		cells = append(cells, formatField(fields[name]))
+	}
+
+	return cells
+}
+
+// formatField returns a custom field value as printed in a table. Dates at
+// midnight UTC are printed as days.
+func formatField(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if t, err := time.Parse(time.RFC3339, value); err == nil && t.Equal(t.Truncate(24*time.Hour)) {
+			return t.Format("2006-01-02")
+		}
+
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cheynewallace/tabby"
+)
+
+// Status is an allowed status, with the statuses objects may change to from
+// it.
+type Status struct {
+	Name string   `json:"name" mapstructure:"name"`
+	Next []string `json:"next" mapstructure:"next"`
+}
+
+// Statuses are the allowed statuses of the objects and the transitions
+// between them. When no statuses are configured, any status is allowed.
+//
+// An unset status may change to any allowed status.
+type Statuses struct {
+	Statuses []Status `json:"statuses"`
+}
+
+// NewStatuses returns the Statuses made of statuses, or an error if a status
+// is declared twice or a transition leads to an undeclared status.
+func NewStatuses(statuses []Status) (*Statuses, error) {
+	s := &Statuses{Statuses: []Status{}}
+	declared := map[string]bool{}
+
+	for _, status := range statuses {
+		if status.Name == "" {
+			return nil, fmt.Errorf("Invalid status: the name is empty")
+		}
+
+		if declared[status.Name] {
+			return nil, fmt.Errorf("Invalid status '%s': declared twice", status.Name)
+		}
+
+		declared[status.Name] = true
+
+		if status.Next == nil {
+			status.Next = []string{}
+		}
+
+		s.Statuses = append(s.Statuses, status)
+	}
+
+	for _, status := range s.Statuses {
+		for _, next := range status.Next {
+			if !declared[next] {
+				return nil, fmt.Errorf("Invalid transition from '%s' to '%s': '%s' is not a declared status", status.Name, next, next)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Enforced reports whether statuses are configured, and thus validated.
+func (s *Statuses) Enforced() bool {
+	return s != nil && len(s.Statuses) > 0
+}
+
+// find returns the status named name, or nil.
+func (s *Statuses) find(name string) *Status {
+	for i := range s.Statuses {
+		if s.Statuses[i].Name == name {
+			return &s.Statuses[i]
+		}
+	}
+
+	return nil
+}
+
+// Validate returns an error if status is not allowed. An unset status is
+// always allowed.
+func (s *Statuses) Validate(status string) error {
+	if !s.Enforced() || status == "" || s.find(status) != nil {
+		return nil
+	}
+
+	names := make([]string, len(s.Statuses))
+
+	for i, allowed := range s.Statuses {
+		if strings.EqualFold(allowed.Name, status) {
+			return fmt.Errorf("Invalid status '%s', did you mean '%s'?", status, allowed.Name)
+		}
+
+		names[i] = allowed.Name
+	}
+
+	return fmt.Errorf("Invalid status '%s', must be one of { %s }", status, strings.Join(names, " | "))
+}
+
+// ValidateTransition returns an error if an object may not change from the
+// status from to the status to.
+func (s *Statuses) ValidateTransition(from, to string) error {
+	if !s.Enforced() || from == to {
+		return nil
+	}
+
+	if to == "" {
+		return fmt.Errorf("Invalid transition from '%s': the status cannot be unset", from)
+	}
+
+	if err := s.Validate(to); err != nil {
+		return err
+	}
+
+	current := s.find(from)
+	if current == nil {
+		// Unset, or set before the statuses were configured
+		return nil
+	}
+
+	for _, next := range current.Next {
+		if next == to {
+			return nil
+		}
+	}
+
+	if len(current.Next) == 0 {
+		return fmt.Errorf("Invalid transition from '%s' to '%s': '%s' is final", from, to, from)
+	}
+
+	return fmt.Errorf("Invalid transition from '%s' to '%s', must be one of { %s }", from, to, strings.Join(current.Next, " | "))
+}
+
+func (s *Statuses) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("status", "next")
+
+	for _, status := range s.Statuses {
+		tab.AddLine(status.Name, strings.Join(status.Next, ", "))
+	}
+
+	tab.Print()
+	return nil
+}
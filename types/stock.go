@@ -0,0 +1,120 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockChange adds to, or takes from, the quantity of a component.
+type StockChange struct {
+	Add    float64 `json:"add,omitempty"`
+	Take   float64 `json:"take,omitempty"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// StockMovement records a change of the quantity of a component.
+type StockMovement struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Component primitive.ObjectID `json:"component" bson:"component"`
+
+	// Delta is positive for additions, negative for removals
+	Delta float64 `json:"delta" bson:"delta"`
+
+	// Quantity is the quantity once changed
+	Quantity float64 `json:"quantity" bson:"quantity"`
+
+	Reason   string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Actor    string    `json:"actor" bson:"actor"`
+	RemoteIP string    `json:"remote_ip" bson:"remote_ip"`
+	Time     time.Time `json:"time" bson:"time"`
+}
+
+// Stock is the quantity of a component, with its movements from oldest to
+// newest when requested.
+type Stock struct {
+	ID       primitive.ObjectID `json:"_id" bson:"_id"`
+	Name     string             `json:"name" bson:"name"`
+	Quantity float64            `json:"quantity" bson:"quantity"`
+	Unit     string             `json:"unit,omitempty" bson:"unit"`
+	LowStock *float64           `json:"low_stock,omitempty" bson:"low_stock"`
+
+	Movements []StockMovement `json:"movements,omitempty" bson:"-"`
+}
+
+// Low reports whether the quantity is at or under the low stock threshold.
+func (s Stock) Low() bool {
+	return s.LowStock != nil && s.Quantity <= *s.LowStock
+}
+
+func (s *Stock) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("id", "name", "quantity", "low_stock")
+	tab.AddLine(s.ID.Hex(), s.Name, formatQuantity(s.Quantity, s.Unit), formatLowStock(*s))
+	tab.Print()
+
+	if len(s.Movements) > 0 {
+		fmt.Println()
+
+		tab = tabby.New()
+
+		tab.AddHeader("time", "delta", "quantity", "actor", "reason")
+
+		for _, movement := range s.Movements {
+			tab.AddLine(movement.Time.Format(time.RFC3339), fmt.Sprintf("%+g", movement.Delta), formatQuantity(movement.Quantity, s.Unit), movement.Actor, movement.Reason)
+		}
+
+		tab.Print()
+	}
+
+	return nil
+}
+
+// StockLevels lists the stock of several components.
+type StockLevels struct {
+	Stock []Stock `json:"stock"`
+}
+
+func (s *StockLevels) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("id", "name", "quantity", "low_stock")
+
+	for _, stock := range s.Stock {
+		tab.AddLine(stock.ID.Hex(), stock.Name, formatQuantity(stock.Quantity, stock.Unit), formatLowStock(stock))
+	}
+
+	tab.Print()
+	return nil
+}
+
+// formatQuantity returns quantity followed by its unit, e.g. "12.5 g".
+func formatQuantity(quantity float64, unit string) string {
+	formatted := strconv.FormatFloat(quantity, 'f', -1, 64)
+
+	if unit != "" {
+		formatted += " " + unit
+	}
+
+	return formatted
+}
+
+// formatLowStock returns the low stock threshold of stock, flagged when the
+// stock is low.
+func formatLowStock(stock Stock) string {
+	if stock.LowStock == nil {
+		return ""
+	}
+
+	formatted := strconv.FormatFloat(*stock.LowStock, 'f', -1, 64)
+
+	if stock.Low() {
+		formatted += " (low)"
+	}
+
+	return formatted
+}
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TagSeparator separates the key from the value of structured tags, e.g.
+// "type=ram".
+const TagSeparator = "="
+
+// ParseTag splits a structured tag into its key and value. ok is false for
+// free tags, which have no key.
+func ParseTag(tag string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(tag, TagSeparator)
+	if !ok || key == "" {
+		return "", "", false
+	}
+
+	return key, value, true
+}
+
+// Tag returns the structured tag made of key and value.
+func Tag(key, value string) string {
+	return key + TagSeparator + value
+}
+
+// TagValue is a distinct value of a tag, with the number of objects having
+// it.
+type TagValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// TagKey is a key of structured tags, with the number of objects having it
+// and its distinct values, from the most used.
+type TagKey struct {
+	Key    string     `json:"key"`
+	Count  int        `json:"count"`
+	Values []TagValue `json:"values"`
+}
+
+// TagCatalog lists the tags in use by components, assemblies, kits and
+// locations.
+type TagCatalog struct {
+	// Keys of the structured tags, in alphabetical order
+	Keys []TagKey `json:"keys"`
+
+	// Free tags, which are not of the form key=value, from the most used
+	Free []TagValue `json:"free"`
+}
+
+// NewTagCatalog returns the catalog of the tags of a list of objects, each
+// given as its list of tags.
+func NewTagCatalog(objects [][]string) *TagCatalog {
+	keys := map[string]map[string]int{}
+	keyCounts := map[string]int{}
+	free := map[string]int{}
+
+	for _, tags := range objects {
+		// Objects are counted once per key, even with several of its values
+		seenKeys := map[string]bool{}
+		seenTags := map[string]bool{}
+
+		for _, tag := range tags {
+			if seenTags[tag] {
+				continue
+			}
+			seenTags[tag] = true
+
+			key, value, ok := ParseTag(tag)
+			if !ok {
+				free[tag]++
+				continue
+			}
+
+			if keys[key] == nil {
+				keys[key] = map[string]int{}
+			}
+			keys[key][value]++
+
+			if !seenKeys[key] {
+				seenKeys[key] = true
+				keyCounts[key]++
+			}
+		}
+	}
+
+	catalog := &TagCatalog{Keys: []TagKey{}, Free: tagValues(free)}
+
+	for key, values := range keys {
+		catalog.Keys = append(catalog.Keys, TagKey{
+			Key:    key,
+			Count:  keyCounts[key],
+			Values: tagValues(values),
+		})
+	}
+
+	sort.Slice(catalog.Keys, func(i, j int) bool {
+		return catalog.Keys[i].Key < catalog.Keys[j].Key
+	})
+
+	return catalog
+}
+
+// tagValues returns counts as a list sorted from the most used value.
+func tagValues(counts map[string]int) []TagValue {
+	values := []TagValue{}
+
+	for value, count := range counts {
+		values = append(values, TagValue{Value: value, Count: count})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+
+		return values[i].Value < values[j].Value
+	})
+
+	return values
+}
+
+func (t *TagCatalog) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("key", "count", "values")
+
+	for _, key := range t.Keys {
+		tab.AddLine(key.Key, key.Count, formatTagValues(key.Values))
+	}
+
+	if len(t.Free) > 0 {
+		tab.AddLine("(free)", "", formatTagValues(t.Free))
+	}
+
+	tab.Print()
+	return nil
+}
+
+// formatTagValues returns values as "ram (3), cpu (2)".
+func formatTagValues(values []TagValue) string {
+	formatted := make([]string, len(values))
+
+	for i, value := range values {
+		formatted[i] = fmt.Sprintf("%s (%d)", value.Value, value.Count)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// TagRename renames the tag From to To on every object. With Key, every tag
+// of the key From is renamed to the key To instead, keeping its value, e.g.
+// "mfr=generic" to "manufacturer=generic".
+type TagRename struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Key    bool   `json:"key,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// TagMerge replaces every tag in From by the single tag To on every object.
+type TagMerge struct {
+	From   []string `json:"from"`
+	To     string   `json:"to"`
+	DryRun bool     `json:"dry_run,omitempty"`
+}
+
+// TagApply adds and removes tags on every object matching a Filter, sent as
+// the query parameters of the request.
+type TagApply struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+
+	// Kinds restricts the change to "component", "assembly", "kit" or
+	// "location" objects, every kind when empty
+	Kinds []string `json:"kinds,omitempty"`
+
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// RetaggedObject is an object whose tags were changed by a bulk operation.
+type RetaggedObject struct {
+	Collection string             `json:"collection"`
+	ID         primitive.ObjectID `json:"_id"`
+	Name       string             `json:"name"`
+	Tags       []string           `json:"tags"`
+}
+
+// RetagResult lists the objects changed by TagRename, TagMerge or TagApply,
+// or that would be changed on dry runs.
+type RetagResult struct {
+	DryRun        bool             `json:"dry_run"`
+	Objects       []RetaggedObject `json:"objects"`
+	ModifiedCount int64            `json:"modified_count"`
+}
+
+func (r *RetagResult) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("id", "collection", "name", "tags")
+
+	for _, object := range r.Objects {
+		tab.AddLine(object.ID.Hex(), object.Collection, object.Name, strings.Join(object.Tags, ", "))
+	}
+
+	tab.Print()
+
+	if r.DryRun {
+		fmt.Printf("\nObjects that would be modified (dry run): %d\n", len(r.Objects))
+	} else {
+		fmt.Printf("\nObjects modified: %d\n", r.ModifiedCount)
+	}
+
+	return nil
+}
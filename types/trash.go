@@ -0,0 +1,43 @@
+package types
+
+import (
+	"time"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrashedObject is a deleted object, which can be restored until it is
+// purged.
+type TrashedObject struct {
+	Collection string             `json:"collection"`
+	ID         primitive.ObjectID `json:"_id"`
+	Name       string             `json:"name"`
+	DeletedAt  time.Time          `json:"deleted_at"`
+
+	// PurgeAt is unset when the trash is only purged by hand
+	PurgeAt *time.Time `json:"purge_at,omitempty"`
+}
+
+// Trash lists the deleted objects, from newest to oldest.
+type Trash struct {
+	Objects []TrashedObject `json:"trash"`
+}
+
+func (t *Trash) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("id", "collection", "name", "deleted_at", "purge_at")
+
+	for _, object := range t.Objects {
+		purgeAt := "never"
+		if object.PurgeAt != nil {
+			purgeAt = object.PurgeAt.Format(time.RFC3339)
+		}
+
+		tab.AddLine(object.ID.Hex(), object.Collection, object.Name, object.DeletedAt.Format(time.RFC3339), purgeAt)
+	}
+
+	tab.Print()
+	return nil
+}
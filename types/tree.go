@@ -0,0 +1,138 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Node describes an object of a Tree or of Relatives.
+type Node struct {
+	Kind   string             `json:"kind"`
+	ID     primitive.ObjectID `json:"_id"`
+	Name   string             `json:"name"`
+	Status string             `json:"status,omitempty"`
+	Tags   []string           `json:"tags,omitempty"`
+}
+
+// Tree is an object with the objects targeting it, recursively: the
+// assemblies and components of a kit, and the components of its assemblies.
+type Tree struct {
+	Node
+
+	// Children are the assemblies, then the components, targeting the object
+	Children []Tree `json:"children"`
+
+	// Truncated is set when the object has children that were not listed
+	// because of the depth limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TabbyPrint prints the tree indented, with a line per object.
+func (t *Tree) TabbyPrint() error {
+	fmt.Println(t.line())
+
+	t.printChildren("")
+
+	return nil
+}
+
+// printChildren prints the children of t, each line starting with prefix.
+func (t *Tree) printChildren(prefix string) {
+	for i, child := range t.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(t.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		fmt.Println(prefix + branch + child.line())
+
+		child.printChildren(prefix + indent)
+	}
+
+	if t.Truncated {
+		fmt.Println(prefix + "└── ...")
+	}
+}
+
+// TabbyPrint prints the object on a single line.
+func (n *Node) TabbyPrint() error {
+	fmt.Println(n.line())
+
+	return nil
+}
+
+// line describes n, e.g. `assembly "PC" 64212ede8e7046c7a1e88557 [in use] type=pc`.
+func (n Node) line() string {
+	line := fmt.Sprintf("%s %q %s", n.Kind, n.Name, n.ID.Hex())
+
+	if n.Status != "" {
+		line += fmt.Sprintf(" [%s]", n.Status)
+	}
+
+	if len(n.Tags) > 0 {
+		line += " " + strings.Join(n.Tags, " ")
+	}
+
+	return line
+}
+
+// Relatives are the objects related to Object, either the objects targeting
+// it, or its ancestors from its target up to the kit containing it.
+type Relatives struct {
+	Object  Node   `json:"object"`
+	Objects []Node `json:"objects"`
+}
+
+func (r *Relatives) TabbyPrint() error {
+	tab := tabby.New()
+
+	tab.AddHeader("kind", "id", "name", "status", "tags")
+
+	for _, object := range r.Objects {
+		tagsJSON, err := json.Marshal(object.Tags)
+		if err != nil {
+			return err
+		}
+
+		tab.AddLine(object.Kind, object.ID.Hex(), object.Name, object.Status, string(tagsJSON))
+	}
+
+	tab.Print()
+	return nil
+}
+
+// Move changes the target of an object, which moves every object it contains
+// along with it.
+type Move struct {
+	// Target is the ObjectID of the new target, or empty to unset it
+	Target string `json:"target"`
+}
+
+// Rewrite replaces Old by New.
+type Rewrite struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// KitClone copies a kit with its assemblies and components, which get new
+// ObjectIDs.
+type KitClone struct {
+	// Name of the new kit, instead of the name of the kit rewritten by
+	// Names
+	Name string `json:"name,omitempty"`
+
+	// Names rewrites the names of the copies, Old being replaced by New
+	// wherever it appears, in order
+	Names []Rewrite `json:"names,omitempty"`
+
+	// Tags rewrites the tags of the copies, the tag Old being replaced by
+	// New, or removed if New is empty
+	Tags []Rewrite `json:"tags,omitempty"`
+
+	// AddTags are added to every copy
+	AddTags []string `json:"add_tags,omitempty"`
+}
@@ -3,6 +3,8 @@ package types
 
 import (
 	"encoding/json"
+	"net/url"
+	"strconv"
 
 	"github.com/cheynewallace/tabby"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,11 +18,29 @@ type Component struct {
 
 	// A component's Target should point to a kit's or assembly's ObjectID
 	Target primitive.ObjectID `json:"target"`
+
+	// A component's Location should point to a location's ObjectID, and is
+	// only set when it has no Target
+	Location primitive.ObjectID `json:"location" bson:"location,omitempty"`
+
+	// Quantity is set for consumables, e.g. screws, tracked as a single
+	// component with a stock instead of one component per item. See Stock.
+	Quantity *float64 `json:"quantity" bson:"quantity,omitempty"`
+	Unit     string   `json:"unit" bson:"unit,omitempty"` // Unit of Quantity, e.g. "g", none for items
+
+	// LowStock is the Quantity at or under which the stock is low
+	LowStock *float64 `json:"low_stock" bson:"low_stock,omitempty"`
+
+	// Fields are the custom fields of the component, see Schema
+	Fields map[string]interface{} `json:"fields" bson:"fields,omitempty"`
 }
 
 type ComponentWithID struct {
 	ID primitive.ObjectID `json:"_id"`
 	Component
+
+	// Version is incremented by every change, see the ETag and If-Match headers
+	Version int64 `json:"version"`
 }
 
 type Assembly struct {
@@ -30,22 +50,41 @@ type Assembly struct {
 
 	// An assembly's Target should point to a kit's ObjectID
 	Target primitive.ObjectID `json:"target"`
+
+	// An assembly's Location should point to a location's ObjectID, and is
+	// only set when it has no Target
+	Location primitive.ObjectID `json:"location" bson:"location,omitempty"`
+
+	// Fields are the custom fields of the assembly, see Schema
+	Fields map[string]interface{} `json:"fields" bson:"fields,omitempty"`
 }
 
 type AssemblyWithID struct {
 	ID primitive.ObjectID `json:"_id"`
 	Assembly
+
+	// Version is incremented by every change, see the ETag and If-Match headers
+	Version int64 `json:"version"`
 }
 
 type Kit struct {
 	Name   string   `json:"name"`
 	Tags   []string `json:"tags"`
 	Status string   `json:"status"`
+
+	// A kit's Location should point to a location's ObjectID
+	Location primitive.ObjectID `json:"location" bson:"location,omitempty"`
+
+	// Fields are the custom fields of the kit, see Schema
+	Fields map[string]interface{} `json:"fields" bson:"fields,omitempty"`
 }
 
 type KitWithID struct {
 	ID primitive.ObjectID `json:"_id"`
 	Kit
+
+	// Version is incremented by every change, see the ETag and If-Match headers
+	Version int64 `json:"version"`
 }
 
 type Components struct {
@@ -54,6 +93,7 @@ type Components struct {
 
 type ComponentsWithID struct {
 	ComponentsWithID []ComponentWithID `json:"components"`
+	Page
 }
 
 type Assemblies struct {
@@ -62,6 +102,7 @@ type Assemblies struct {
 
 type AssembliesWithID struct {
 	AssembliesWithID []AssemblyWithID `json:"assemblies"`
+	Page
 }
 
 type Kits struct {
@@ -70,6 +111,7 @@ type Kits struct {
 
 type KitsWithID struct {
 	KitsWithID []KitWithID `json:"kits"`
+	Page
 }
 
 type TabbyPrinter interface {
@@ -79,7 +121,9 @@ type TabbyPrinter interface {
 func (c *Component) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status", "target")
+	names := fieldNames(c.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status", "target"}, names)...)
 
 	tags, err := json.Marshal(c.Tags)
 	if err != nil {
@@ -91,7 +135,7 @@ func (c *Component) TabbyPrint() error {
 		return err
 	}
 
-	t.AddLine(c.Name, string(tags), c.Status, string(targetid))
+	t.AddLine(fieldCells([]interface{}{c.Name, string(tags), c.Status, string(targetid)}, names, c.Fields)...)
 
 	t.Print()
 	return nil
@@ -100,7 +144,9 @@ func (c *Component) TabbyPrint() error {
 func (c *ComponentWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status", "target")
+	names := fieldNames(c.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status", "target"}, names)...)
 
 	tags, err := json.Marshal(c.Tags)
 	if err != nil {
@@ -117,7 +163,7 @@ func (c *ComponentWithID) TabbyPrint() error {
 		return err
 	}
 
-	t.AddLine(string(objectid), c.Name, string(tags), c.Status, string(targetid))
+	t.AddLine(fieldCells([]interface{}{string(objectid), c.Name, string(tags), c.Status, string(targetid)}, names, c.Fields)...)
 
 	t.Print()
 	return nil
@@ -126,7 +172,15 @@ func (c *ComponentWithID) TabbyPrint() error {
 func (c *Components) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status", "target")
+	fields := make([]map[string]interface{}, len(c.Components))
+
+	for i, component := range c.Components {
+		fields[i] = component.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status", "target"}, names)...)
 
 	for _, component := range c.Components {
 		tags, err := json.Marshal(component.Tags)
@@ -139,7 +193,7 @@ func (c *Components) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(component.Name, string(tags), component.Status, string(targetid))
+		t.AddLine(fieldCells([]interface{}{component.Name, string(tags), component.Status, string(targetid)}, names, component.Fields)...)
 	}
 
 	t.Print()
@@ -149,7 +203,15 @@ func (c *Components) TabbyPrint() error {
 func (c *ComponentsWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status", "target")
+	fields := make([]map[string]interface{}, len(c.ComponentsWithID))
+
+	for i, component := range c.ComponentsWithID {
+		fields[i] = component.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status", "target"}, names)...)
 
 	for _, component := range c.ComponentsWithID {
 		tags, err := json.Marshal(component.Tags)
@@ -167,7 +229,7 @@ func (c *ComponentsWithID) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(string(objectid), component.Name, string(tags), component.Status, string(targetid))
+		t.AddLine(fieldCells([]interface{}{string(objectid), component.Name, string(tags), component.Status, string(targetid)}, names, component.Fields)...)
 	}
 
 	t.Print()
@@ -177,7 +239,9 @@ func (c *ComponentsWithID) TabbyPrint() error {
 func (a *Assembly) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status", "target")
+	names := fieldNames(a.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status", "target"}, names)...)
 
 	tags, err := json.Marshal(a.Tags)
 	if err != nil {
@@ -189,7 +253,7 @@ func (a *Assembly) TabbyPrint() error {
 		return err
 	}
 
-	t.AddLine(a.Name, string(tags), a.Status, string(targetid))
+	t.AddLine(fieldCells([]interface{}{a.Name, string(tags), a.Status, string(targetid)}, names, a.Fields)...)
 
 	t.Print()
 	return nil
@@ -198,7 +262,9 @@ func (a *Assembly) TabbyPrint() error {
 func (a *AssemblyWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status", "target")
+	names := fieldNames(a.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status", "target"}, names)...)
 
 	tags, err := json.Marshal(a.Tags)
 	if err != nil {
@@ -215,7 +281,7 @@ func (a *AssemblyWithID) TabbyPrint() error {
 		return err
 	}
 
-	t.AddLine(string(objectid), a.Name, string(tags), a.Status, string(targetid))
+	t.AddLine(fieldCells([]interface{}{string(objectid), a.Name, string(tags), a.Status, string(targetid)}, names, a.Fields)...)
 
 	t.Print()
 	return nil
@@ -224,7 +290,15 @@ func (a *AssemblyWithID) TabbyPrint() error {
 func (a *Assemblies) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status", "target")
+	fields := make([]map[string]interface{}, len(a.Assemblies))
+
+	for i, assembly := range a.Assemblies {
+		fields[i] = assembly.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status", "target"}, names)...)
 
 	for _, assembly := range a.Assemblies {
 		tags, err := json.Marshal(assembly.Tags)
@@ -237,7 +311,7 @@ func (a *Assemblies) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(assembly.Name, string(tags), assembly.Status, string(targetid))
+		t.AddLine(fieldCells([]interface{}{assembly.Name, string(tags), assembly.Status, string(targetid)}, names, assembly.Fields)...)
 	}
 
 	t.Print()
@@ -247,7 +321,15 @@ func (a *Assemblies) TabbyPrint() error {
 func (a *AssembliesWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status", "target")
+	fields := make([]map[string]interface{}, len(a.AssembliesWithID))
+
+	for i, assembly := range a.AssembliesWithID {
+		fields[i] = assembly.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status", "target"}, names)...)
 
 	for _, assembly := range a.AssembliesWithID {
 		tags, err := json.Marshal(assembly.Tags)
@@ -265,7 +347,7 @@ func (a *AssembliesWithID) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(string(objectid), assembly.Name, string(tags), assembly.Status, string(targetid))
+		t.AddLine(fieldCells([]interface{}{string(objectid), assembly.Name, string(tags), assembly.Status, string(targetid)}, names, assembly.Fields)...)
 	}
 
 	t.Print()
@@ -275,14 +357,16 @@ func (a *AssembliesWithID) TabbyPrint() error {
 func (k *Kit) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status")
+	names := fieldNames(k.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status"}, names)...)
 
 	tags, err := json.Marshal(k.Tags)
 	if err != nil {
 		return err
 	}
 
-	t.AddLine(k.Name, string(tags), k.Status)
+	t.AddLine(fieldCells([]interface{}{k.Name, string(tags), k.Status}, names, k.Fields)...)
 
 	t.Print()
 	return nil
@@ -291,7 +375,9 @@ func (k *Kit) TabbyPrint() error {
 func (k *KitWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status")
+	names := fieldNames(k.Fields)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status"}, names)...)
 
 	tags, err := json.Marshal(k.Tags)
 	if err != nil {
@@ -303,7 +389,7 @@ func (k *KitWithID) TabbyPrint() error {
 		return err
 	}
 
-	t.AddLine(string(objectid), k.Name, string(tags), k.Status)
+	t.AddLine(fieldCells([]interface{}{string(objectid), k.Name, string(tags), k.Status}, names, k.Fields)...)
 
 	t.Print()
 	return nil
@@ -312,7 +398,15 @@ func (k *KitWithID) TabbyPrint() error {
 func (k *Kits) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("name", "tags", "status")
+	fields := make([]map[string]interface{}, len(k.Kits))
+
+	for i, kit := range k.Kits {
+		fields[i] = kit.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"name", "tags", "status"}, names)...)
 
 	for _, kit := range k.Kits {
 		tags, err := json.Marshal(kit.Tags)
@@ -320,7 +414,7 @@ func (k *Kits) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(kit.Name, string(tags), kit.Status)
+		t.AddLine(fieldCells([]interface{}{kit.Name, string(tags), kit.Status}, names, kit.Fields)...)
 	}
 
 	t.Print()
@@ -330,7 +424,15 @@ func (k *Kits) TabbyPrint() error {
 func (k *KitsWithID) TabbyPrint() error {
 	t := tabby.New()
 
-	t.AddHeader("id", "name", "tags", "status")
+	fields := make([]map[string]interface{}, len(k.KitsWithID))
+
+	for i, kit := range k.KitsWithID {
+		fields[i] = kit.Fields
+	}
+
+	names := fieldNames(fields...)
+
+	t.AddHeader(fieldColumns([]interface{}{"id", "name", "tags", "status"}, names)...)
 
 	for _, kit := range k.KitsWithID {
 		tags, err := json.Marshal(kit.Tags)
@@ -343,7 +445,7 @@ func (k *KitsWithID) TabbyPrint() error {
 			return err
 		}
 
-		t.AddLine(string(objectid), kit.Name, string(tags), kit.Status)
+		t.AddLine(fieldCells([]interface{}{string(objectid), kit.Name, string(tags), kit.Status}, names, kit.Fields)...)
 	}
 
 	t.Print()
@@ -440,3 +542,191 @@ func (r InsertResult) TabbyPrint() error {
 	t.Print()
 	return nil
 }
+
+const (
+	FilterMatchAll = "all" // Default
+	FilterMatchAny = "any"
+)
+
+/*
+Filter selects the objects returned by the list routes.
+
+Every non-zero field is a condition. Conditions are combined with AND when
+Match is "all" (the default), or with OR when Match is "any".
+
+Filter fields are read from, and written to, the query parameters named in
+their `query` tag. TagValues is read from the "tag.<key>" parameters instead,
+and FieldValues from the "field.<name>" parameters.
+*/
+type Filter struct {
+	Name       string   `query:"name"`        // Name is exactly Name
+	NamePrefix string   `query:"name_prefix"` // Name starts with NamePrefix
+	NameRegex  string   `query:"name_regex"`  // Name matches the regular expression NameRegex
+	Status     []string `query:"status"`      // Status is one of Status
+	Tags       []string `query:"tag"`         // Has every tag in Tags, e.g. "type=ram"
+	NotTags    []string `query:"not_tag"`     // Has none of the tags in NotTags
+	Target     string   `query:"target"`      // Target is the ObjectID Target
+	Untargeted bool     `query:"untargeted"`  // Target is unset
+	Location   string   `query:"location"`    // Location is the ObjectID Location
+	Match      string   `query:"match"`       // "all" or "any"
+
+	// TagValues maps tag keys to values, e.g. "type" to ["ram", "cpu"]. An
+	// object matches a key if it has a "key=value" tag with any of its
+	// values, or any "key=" tag if one of the values is "*".
+	TagValues map[string][]string
+
+	// FieldValues maps the names of custom fields to values, e.g. "serial"
+	// to ["SN-1234"]. An object matches a name if its field has any of the
+	// values. Names suffixed by ".min" or ".max" match the objects whose
+	// field is at least, or at most, the value instead, e.g. "price.max".
+	// Values are converted to the type of the field, see Field.Parse.
+	FieldValues map[string][]string
+}
+
+// TagValuesPrefix prefixes the query parameters read into Filter.TagValues,
+// e.g. "tag.type=ram".
+const TagValuesPrefix = "tag."
+
+// FieldValuesPrefix prefixes the query parameters read into
+// Filter.FieldValues, e.g. "field.serial=SN-1234".
+const FieldValuesPrefix = "field."
+
+// Values returns the query parameters representing the Filter.
+func (f Filter) Values() url.Values {
+	values := url.Values{}
+
+	if f.Name != "" {
+		values.Set("name", f.Name)
+	}
+
+	if f.NamePrefix != "" {
+		values.Set("name_prefix", f.NamePrefix)
+	}
+
+	if f.NameRegex != "" {
+		values.Set("name_regex", f.NameRegex)
+	}
+
+	for _, status := range f.Status {
+		values.Add("status", status)
+	}
+
+	for _, tag := range f.Tags {
+		values.Add("tag", tag)
+	}
+
+	for _, tag := range f.NotTags {
+		values.Add("not_tag", tag)
+	}
+
+	for key, tagValues := range f.TagValues {
+		for _, value := range tagValues {
+			values.Add(TagValuesPrefix+key, value)
+		}
+	}
+
+	for name, fieldValues := range f.FieldValues {
+		for _, value := range fieldValues {
+			values.Add(FieldValuesPrefix+name, value)
+		}
+	}
+
+	if f.Target != "" {
+		values.Set("target", f.Target)
+	}
+
+	if f.Untargeted {
+		values.Set("untargeted", "true")
+	}
+
+	if f.Location != "" {
+		values.Set("location", f.Location)
+	}
+
+	if f.Match != "" {
+		values.Set("match", f.Match)
+	}
+
+	return values
+}
+
+/*
+ListOptions selects the page, order and fields of the objects returned by the
+list routes.
+
+ListOptions fields are read from, and written to, the query parameters named
+in their `query` tag.
+*/
+type ListOptions struct {
+	Limit  int64  `query:"limit"`  // Maximum number of objects in the page
+	After  string `query:"after"`  // Cursor returned as Page.Next by the previous page
+	Sort   string `query:"sort"`   // Comma-separated fields, prefixed by '-' for descending order, e.g. "name,-status"
+	Fields string `query:"fields"` // Comma-separated fields to return, e.g. "name,tags"
+}
+
+// Values returns the query parameters representing the ListOptions.
+func (o ListOptions) Values() url.Values {
+	values := url.Values{}
+
+	if o.Limit != 0 {
+		values.Set("limit", strconv.FormatInt(o.Limit, 10))
+	}
+
+	if o.After != "" {
+		values.Set("after", o.After)
+	}
+
+	if o.Sort != "" {
+		values.Set("sort", o.Sort)
+	}
+
+	if o.Fields != "" {
+		values.Set("fields", o.Fields)
+	}
+
+	return values
+}
+
+// Page holds the pagination details returned along with the objects of the
+// list routes.
+type Page struct {
+	// Total is the number of objects matching the filter, in every page.
+	Total int64 `json:"total"`
+
+	// Next is the cursor of the following page, empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// Values of the "on_referenced" query parameter of the delete routes, which
+// decides what happens to the objects whose target is the deleted object.
+const (
+	OnReferencedReject  = "reject"  // Default, refuse to delete a referenced object
+	OnReferencedOrphan  = "orphan"  // Unset the target of the referrers
+	OnReferencedCascade = "cascade" // Delete the referrers, and their own referrers
+)
+
+// Referrer is an object whose target is another object.
+type Referrer struct {
+	Collection string             `json:"collection"`
+	ID         primitive.ObjectID `json:"_id"`
+	Name       string             `json:"name"`
+}
+
+// ReferencedDetails are the details of the Error returned when deleting an
+// object that is still the target of other objects.
+type ReferencedDetails struct {
+	Referrers []Referrer `json:"referrers"`
+}
+
+func (r ReferencedDetails) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("collection", "id", "name")
+
+	for _, referrer := range r.Referrers {
+		t.AddLine(referrer.Collection, referrer.ID.Hex(), referrer.Name)
+	}
+
+	t.Print()
+	return nil
+}
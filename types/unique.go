@@ -0,0 +1,219 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/cheynewallace/tabby"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UniqueKeyName is the key of a UniqueRule on the name of the objects. The
+// other keys are tag keys prefixed by TagValuesPrefix, e.g. "tag.serial".
+const UniqueKeyName = "name"
+
+// uniqueKinds are the kinds of objects a UniqueRule may apply to.
+var uniqueKinds = []string{"component", "assembly", "kit", "location"}
+
+// UniqueRule makes the values of Key unique among the objects of Kind that
+// are not in the trash, e.g. the "serial=" tags of components.
+type UniqueRule struct {
+	Kind string `json:"kind" mapstructure:"kind"`
+	Key  string `json:"key" mapstructure:"key"` // UniqueKeyName, or "tag.<key>"
+}
+
+// TagKey returns the tag key of the rule, and false if it is on the name.
+func (r UniqueRule) TagKey() (string, bool) {
+	if !strings.HasPrefix(r.Key, TagValuesPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(r.Key, TagValuesPrefix), true
+}
+
+// UniqueRules are the keys whose values the objects of a kind cannot share.
+// When no rules are configured, names and tags may be shared.
+type UniqueRules struct {
+	Rules []UniqueRule `json:"rules"`
+}
+
+// NewUniqueRules returns the UniqueRules made of rules, or an error if a
+// rule is invalid or declared twice.
+func NewUniqueRules(rules []UniqueRule) (*UniqueRules, error) {
+	u := &UniqueRules{Rules: []UniqueRule{}}
+	declared := map[UniqueRule]bool{}
+
+	for _, rule := range rules {
+		kindValid := false
+
+		for _, kind := range uniqueKinds {
+			kindValid = kindValid || kind == rule.Kind
+		}
+
+		if !kindValid {
+			return nil, fmt.Errorf("Invalid kind '%s', must be one of { %s }", rule.Kind, strings.Join(uniqueKinds, " | "))
+		}
+
+		if err := ValidateUniqueKey(rule.Key); err != nil {
+			return nil, fmt.Errorf("%s of kind '%s'", err, rule.Kind)
+		}
+
+		if declared[rule] {
+			return nil, fmt.Errorf("Invalid key '%s' of kind '%s': declared twice", rule.Key, rule.Kind)
+		}
+
+		declared[rule] = true
+
+		u.Rules = append(u.Rules, rule)
+	}
+
+	return u, nil
+}
+
+// ValidateUniqueKey returns an error if key is neither UniqueKeyName nor a
+// valid tag key prefixed by TagValuesPrefix.
+func ValidateUniqueKey(key string) error {
+	tagKey, ok := UniqueRule{Key: key}.TagKey()
+	if !ok {
+		if key != UniqueKeyName {
+			return fmt.Errorf("Invalid key '%s', must be '%s' or '%s<key>'", key, UniqueKeyName, TagValuesPrefix)
+		}
+
+		return nil
+	}
+
+	if tagKey == "" || strings.ContainsAny(tagKey, TagSeparator+".$") {
+		return fmt.Errorf("Invalid key '%s': the tag key cannot be empty, nor contain '%s', '.' or '$'", key, TagSeparator)
+	}
+
+	return nil
+}
+
+// Keys returns the unique keys of the objects of kind.
+func (u *UniqueRules) Keys(kind string) []string {
+	keys := []string{}
+
+	if u != nil {
+		for _, rule := range u.Rules {
+			if rule.Kind == kind {
+				keys = append(keys, rule.Key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// UniqueValues returns the values of key of an object with name and tags,
+// e.g. the values of its "serial=" tags for "tag.serial". Empty values are
+// left out.
+func UniqueValues(key, name string, tags []string) []string {
+	values := []string{}
+
+	if key == UniqueKeyName {
+		if name != "" {
+			values = append(values, name)
+		}
+
+		return values
+	}
+
+	tagKey := strings.TrimPrefix(key, TagValuesPrefix)
+
+	for _, tag := range tags {
+		k, value, ok := ParseTag(tag)
+		if !ok || k != tagKey || value == "" {
+			continue
+		}
+
+		duplicate := false
+
+		for _, v := range values {
+			duplicate = duplicate || v == value
+		}
+
+		if !duplicate {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// NearValue returns value without case nor whitespace, such that values
+// differing only by them are near duplicates, e.g. "SN 1234 " and "sn1234".
+func NearValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+
+		return unicode.ToLower(r)
+	}, value)
+}
+
+/*
+DuplicateFilter selects the kinds and keys searched for near duplicates.
+
+DuplicateFilter fields are read from, and written to, the query parameters
+named in their `query` tag. Without Kinds, every kind is searched. Without
+Keys, the name and the unique keys of each kind are.
+*/
+type DuplicateFilter struct {
+	Kinds []string `query:"kind"` // e.g. "component"
+	Keys  []string `query:"key"`  // UniqueKeyName or "tag.<key>"
+}
+
+// Values returns the query parameters representing the DuplicateFilter.
+func (f DuplicateFilter) Values() url.Values {
+	values := url.Values{}
+
+	for _, kind := range f.Kinds {
+		values.Add("kind", kind)
+	}
+
+	for _, key := range f.Keys {
+		values.Add("key", key)
+	}
+
+	return values
+}
+
+// DuplicateObject is an object in a DuplicateGroup, with its value of the
+// key.
+type DuplicateObject struct {
+	ID    primitive.ObjectID `json:"_id"`
+	Name  string             `json:"name"`
+	Value string             `json:"value"`
+}
+
+// DuplicateGroup are objects of a kind whose values of a key are near
+// duplicates, all equal to Value once passed to NearValue.
+type DuplicateGroup struct {
+	Kind    string            `json:"kind"`
+	Key     string            `json:"key"`
+	Value   string            `json:"value"`
+	Objects []DuplicateObject `json:"objects"`
+}
+
+// Duplicates lists the groups of near duplicates, by kind, key and value.
+type Duplicates struct {
+	Groups []DuplicateGroup `json:"groups"`
+}
+
+func (d *Duplicates) TabbyPrint() error {
+	t := tabby.New()
+
+	t.AddHeader("kind", "key", "near value", "id", "name", "value")
+
+	for _, group := range d.Groups {
+		for _, object := range group.Objects {
+			t.AddLine(group.Kind, group.Key, group.Value, object.ID.Hex(), object.Name, object.Value)
+		}
+	}
+
+	t.Print()
+	return nil
+}